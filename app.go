@@ -52,6 +52,15 @@ type AppConfig struct {
 	Guard      ws.GuardFunc //守护回调
 	HttpServer http.Handler //http server
 	Telemetry  telemetry.Provider
+	LangStore  ws.LangStore //语言包存储
+	WsServer   *ws.Server   //websocket服务，为空时使用 ws.Default()
+
+	Admission *ws.AdmissionPolicy //连接准入策略
+	Heartbeat *ws.HeartbeatPolicy //心跳和空闲断开策略
+	BanStore  ws.BanStore         //封禁存储
+	Presence  *ws.PresencePolicy  //在线状态策略
+
+	SessionPolicies map[string]ws.SessionPolicy //按appId设置的多端登录策略
 
 	RemoteProvider *RemoteProvider //远程配置支持etcd, consul
 
@@ -221,17 +230,24 @@ func Init(options ...Option) *AppConfig {
 	viper.WatchConfig()
 
 	// 注入守护回调
+	server := acf.wsServer()
 	if acf.Guard != nil {
-		ws.SetGuardFunc(acf.Guard)
+		server.Hub().SetGuardFunc(acf.Guard)
 	}
 
 	telemetry.SetProvider(acf.Telemetry)
-
-	//初始化hub
-	ws.InitManager()
 	return acf
 }
 
+// wsServer 应用使用的websocket服务
+func (a *AppConfig) wsServer() *ws.Server {
+	if a.WsServer != nil {
+		return a.WsServer
+	}
+
+	return ws.NewServer(a.HttpServer)
+}
+
 func isConfigFileNotFound(err error) bool {
 	var configFileNotFoundError viper.ConfigFileNotFoundError
 	return errors.As(err, &configFileNotFoundError)
@@ -5,8 +5,6 @@ import (
 	"os"
 
 	"github.com/fatih/color"
-
-	"github.com/wonli/aqi/ws"
 )
 
 func (a *AppConfig) WithHttpServer(svr http.Handler) {
@@ -20,9 +18,32 @@ func (a *AppConfig) Start() {
 	}
 
 	if a.HttpServer != nil {
-		server := ws.NewServer(a.HttpServer)
+		server := a.wsServer()
 		server.SetDataPath(a.DataPath)
 		server.SetIsDev(a.devMode)
+		if a.LangStore != nil {
+			server.SetLangStore(a.LangStore)
+		}
+
+		if a.Admission != nil {
+			server.SetAdmission(*a.Admission)
+		}
+
+		if a.Heartbeat != nil {
+			server.SetHeartbeat(*a.Heartbeat)
+		}
+
+		if a.Presence != nil {
+			server.SetPresence(*a.Presence)
+		}
+
+		if a.BanStore != nil {
+			server.SetBanStore(a.BanStore)
+		}
+
+		for appId, policy := range a.SessionPolicies {
+			server.SetSessionPolicy(appId, policy)
+		}
 		server.Init()
 	}
 
@@ -0,0 +1,586 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// HandlerFunc 处理服务端推送的消息
+//
+// 推送消息在读取协程中按顺序处理，耗时操作需要自行开启协程
+type HandlerFunc func(msg *Message)
+
+// Client aqi websocket 协议客户端
+//
+// 请求格式为 {"id":"1","action":"hi","params":{...}}，响应通过相同的id与请求对应，
+// 没有对应请求的消息按action分发给 On 注册的处理函数
+type Client struct {
+	url    string
+	header http.Header
+
+	reconnect    bool
+	backoffMin   time.Duration
+	backoffMax   time.Duration
+	pingInterval time.Duration
+	timeout      time.Duration
+	onConnect    func(c *Client)
+	onDisconnect func(c *Client, err error)
+
+	mu       sync.Mutex
+	conn     net.Conn
+	connDone chan struct{}
+	ready    chan struct{}
+	closed   bool
+	kicked   bool
+	done     chan struct{}
+	pending  map[string]*pendingCall
+	handlers map[string][]HandlerFunc
+	subs     map[string]subscription
+
+	writeMu sync.Mutex
+	seq     atomic.Uint64
+}
+
+type pendingCall struct {
+	seq    uint64
+	action string
+	ch     chan *Message
+}
+
+type subscription struct {
+	action string
+	params any
+}
+
+// Dial 连接服务端，连接成功后断线会按退避策略自动重连
+func Dial(ctx context.Context, url string, options ...Option) (*Client, error) {
+	c := &Client{
+		url:        url,
+		reconnect:  true,
+		backoffMin: 500 * time.Millisecond,
+		backoffMax: 30 * time.Second,
+		timeout:    10 * time.Second,
+		ready:      make(chan struct{}),
+		done:       make(chan struct{}),
+		pending:    map[string]*pendingCall{},
+		handlers:   map[string][]HandlerFunc{},
+		subs:       map[string]subscription{},
+	}
+
+	for _, option := range options {
+		if option != nil {
+			option(c)
+		}
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setConn(conn, false)
+	go c.run(conn)
+	return c, nil
+}
+
+// Call 发送请求并等待响应，ctx 没有截止时间时使用 WithTimeout 设置的超时时间
+//
+// 响应 code 不为0时同时返回消息和 *Error
+func (c *Client) Call(ctx context.Context, action string, params any) (*Message, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	seq := c.seq.Add(1)
+	id := strconv.FormatUint(seq, 10)
+	call := &pendingCall{seq: seq, action: action, ch: make(chan *Message, 1)}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	c.pending[id] = call
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	err := c.write(ctx, request{Id: id, Action: action, Params: params})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg, ok := <-call.ch:
+		if !ok {
+			return nil, ErrDisconnected
+		}
+
+		return msg, msg.Err()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, ErrClosed
+	}
+}
+
+// Send 发送请求，不等待响应
+func (c *Client) Send(ctx context.Context, action string, params any) error {
+	return c.write(ctx, request{Action: action, Params: params})
+}
+
+// On 注册推送消息的处理函数
+func (c *Client) On(action string, fn HandlerFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.handlers[action] = append(c.handlers[action], fn)
+}
+
+// Off 移除action的全部处理函数
+func (c *Client) Off(action string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.handlers, action)
+}
+
+// OnTopic 处理主题消息，服务端 PubSub.Pub 以主题ID作为action推送
+func (c *Client) OnTopic(topicId string, fn func(msg *TopicMessage)) {
+	c.On(topicId, func(msg *Message) {
+		var tm TopicMessage
+		if err := msg.Bind(&tm); err != nil {
+			return
+		}
+
+		fn(&tm)
+	})
+}
+
+// Subscribe 调用服务端订阅主题的action并处理主题消息
+//
+// 订阅在重连并执行 WithOnConnect 回调后自动恢复
+func (c *Client) Subscribe(ctx context.Context, action string, params any, topicId string, fn func(msg *TopicMessage)) error {
+	c.OnTopic(topicId, fn)
+	_, err := c.Call(ctx, action, params)
+	if err != nil {
+		c.Off(topicId)
+		return err
+	}
+
+	c.mu.Lock()
+	c.subs[topicId] = subscription{action: action, params: params}
+	c.mu.Unlock()
+	return nil
+}
+
+// Unsubscribe 调用服务端取消订阅的action并移除主题消息的处理函数
+func (c *Client) Unsubscribe(ctx context.Context, action string, params any, topicId string) error {
+	c.mu.Lock()
+	delete(c.subs, topicId)
+	c.mu.Unlock()
+
+	c.Off(topicId)
+	_, err := c.Call(ctx, action, params)
+	return err
+}
+
+// Close 关闭连接并停止重连
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+
+	c.closed = true
+	close(c.done)
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	c.writeMu.Lock()
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = ws.WriteFrame(conn, ws.MaskFrame(ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusNormalClosure, ""))))
+	c.writeMu.Unlock()
+
+	return conn.Close()
+}
+
+func (c *Client) run(conn net.Conn) {
+	for {
+		err := c.read(conn)
+		kicked := errors.Is(err, ErrKicked)
+		if kicked {
+			c.mu.Lock()
+			c.kicked = true
+			c.mu.Unlock()
+		}
+
+		c.dropConn(conn, err)
+
+		//被其它设备登录踢下线时不再重连，避免互相踢下线
+		if !c.reconnect || kicked {
+			return
+		}
+
+		conn = c.redial()
+		if conn == nil || !c.setConn(conn, true) {
+			return
+		}
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	d := ws.Dialer{}
+	if c.header != nil {
+		d.Header = ws.HandshakeHeaderHTTP(c.header)
+	}
+
+	conn, br, _, err := d.Dial(ctx, c.url)
+	if err != nil {
+		return nil, err
+	}
+
+	if br != nil {
+		conn = &bufferedConn{Conn: conn, r: br}
+	}
+
+	return conn, nil
+}
+
+func (c *Client) redial() net.Conn {
+	for attempt := 0; ; attempt++ {
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-c.done:
+			timer.Stop()
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		conn, err := c.dial(ctx)
+		cancel()
+		if err == nil {
+			return conn
+		}
+	}
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.backoffMin
+	for i := 0; i < attempt && d < c.backoffMax; i++ {
+		d *= 2
+	}
+
+	if d > c.backoffMax {
+		d = c.backoffMax
+	}
+
+	//加入随机抖动，避免大量客户端同时重连
+	return d/2 + rand.N(d/2+1)
+}
+
+func (c *Client) setConn(conn net.Conn, reconnected bool) bool {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		_ = conn.Close()
+		return false
+	}
+
+	connDone := make(chan struct{})
+	c.conn = conn
+	c.connDone = connDone
+	close(c.ready)
+
+	subs := make([]subscription, 0, len(c.subs))
+	for _, sub := range c.subs {
+		subs = append(subs, sub)
+	}
+	c.mu.Unlock()
+
+	go c.ping(conn, connDone)
+	if c.onConnect != nil || (reconnected && len(subs) > 0) {
+		go func() {
+			if c.onConnect != nil {
+				c.onConnect(c)
+			}
+
+			for _, sub := range subs {
+				_, _ = c.Call(context.Background(), sub.action, sub.params)
+			}
+		}()
+	}
+
+	return true
+}
+
+func (c *Client) dropConn(conn net.Conn, err error) {
+	_ = conn.Close()
+
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+		close(c.connDone)
+		c.ready = make(chan struct{})
+	}
+
+	for id, call := range c.pending {
+		close(call.ch)
+		delete(c.pending, id)
+	}
+	closed := c.closed
+	c.mu.Unlock()
+
+	if c.onDisconnect != nil && !closed {
+		c.onDisconnect(c, err)
+	}
+}
+
+// waitConn 获取当前连接，重连过程中等待连接成功
+func (c *Client) waitConn(ctx context.Context) (net.Conn, error) {
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return nil, ErrClosed
+		}
+
+		if c.conn != nil {
+			conn := c.conn
+			c.mu.Unlock()
+			return conn, nil
+		}
+
+		if c.kicked {
+			c.mu.Unlock()
+			return nil, ErrKicked
+		}
+
+		if !c.reconnect {
+			c.mu.Unlock()
+			return nil, ErrDisconnected
+		}
+
+		ready := c.ready
+		c.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.done:
+			return nil, ErrClosed
+		}
+	}
+}
+
+func (c *Client) write(ctx context.Context, req request) error {
+	if req.Params == nil {
+		req.Params = json.RawMessage("{}")
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	conn, err := c.waitConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetWriteDeadline(deadline)
+	err = wsutil.WriteClientMessage(conn, ws.OpText, data)
+	if err != nil {
+		//关闭连接后由读取协程负责重连
+		_ = conn.Close()
+		return err
+	}
+
+	return nil
+}
+
+// read 读取消息直到连接断开，ping帧由控制帧处理函数回复pong
+func (c *Client) read(conn net.Conn) error {
+	controlHandler := wsutil.ControlFrameHandler(&lockedWriter{c: c, w: conn}, ws.StateClientSide)
+	rd := &wsutil.Reader{
+		Source:         conn,
+		State:          ws.StateClientSide,
+		CheckUTF8:      true,
+		OnIntermediate: controlHandler,
+	}
+
+	for {
+		if c.pingInterval > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(3 * c.pingInterval))
+		}
+
+		hdr, err := rd.NextFrame()
+		if err != nil {
+			return err
+		}
+
+		if hdr.OpCode.IsControl() {
+			err = controlHandler(hdr, rd)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if hdr.OpCode&ws.OpText == 0 {
+			err = rd.Discard()
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		data, err := io.ReadAll(rd)
+		if err != nil {
+			return err
+		}
+
+		if c.route(data) {
+			return ErrKicked
+		}
+	}
+}
+
+// route 分发消息，收到 sys.kicked 时返回 true
+func (c *Client) route(data []byte) bool {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	call := c.matchCall(&msg)
+	if call != nil {
+		call.ch <- &msg
+		c.mu.Unlock()
+		return false
+	}
+
+	handlers := append([]HandlerFunc(nil), c.handlers[msg.Action]...)
+	c.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(&msg)
+	}
+
+	return msg.Action == "sys.kicked"
+}
+
+// matchCall 查找消息对应的请求，需持有锁
+//
+// 服务端部分错误响应不带id（如 request not supported），按action匹配最早的请求
+func (c *Client) matchCall(msg *Message) *pendingCall {
+	if msg.Id != "" {
+		call, ok := c.pending[msg.Id]
+		if ok {
+			delete(c.pending, msg.Id)
+			return call
+		}
+
+		return nil
+	}
+
+	if msg.Code == 0 {
+		return nil
+	}
+
+	var id string
+	var match *pendingCall
+	for k, call := range c.pending {
+		if call.action == msg.Action && (match == nil || call.seq < match.seq) {
+			id, match = k, call
+		}
+	}
+
+	if match != nil {
+		delete(c.pending, id)
+	}
+
+	return match
+}
+
+// ping 定时发送 ping action，服务端回复后重置读取超时
+func (c *Client) ping(conn net.Conn, connDone chan struct{}) {
+	if c.pingInterval <= 0 {
+		return
+	}
+
+	timer := time.NewTicker(c.pingInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			ctx, cancel := context.WithTimeout(context.Background(), c.pingInterval)
+			err := c.write(ctx, request{Action: "ping"})
+			cancel()
+			if err != nil {
+				return
+			}
+		case <-connDone:
+			return
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// lockedWriter 回复控制帧时与普通消息共用写锁
+type lockedWriter struct {
+	c *Client
+	w io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.c.writeMu.Lock()
+	defer l.c.writeMu.Unlock()
+
+	return l.w.Write(p)
+}
+
+// bufferedConn 握手时已读取到缓冲区的数据需要先读出
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (b *bufferedConn) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
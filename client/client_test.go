@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/wonli/aqi/ws"
+)
+
+var routeOnce sync.Once
+
+func newTestServer(t *testing.T) string {
+	t.Helper()
+
+	ws.NewServer(http.NewServeMux())
+	routeOnce.Do(addTestRoutes)
+
+	svr := httptest.NewServer(http.HandlerFunc(ws.HttpHandler))
+	t.Cleanup(svr.Close)
+
+	return "ws" + strings.TrimPrefix(svr.URL, "http")
+}
+
+func addTestRoutes() {
+	r := ws.NewRouter()
+	r.Add("client.echo", func(a *ws.Context) {
+		a.Send(ws.H{"name": a.Get("name")})
+	})
+
+	r.Add("client.push", func(a *ws.Context) {
+		a.SendActionData("client.notice", ws.H{"text": a.Get("text")})
+		a.SendOk()
+	})
+
+	r.Add("client.fail", func(a *ws.Context) {
+		a.SendCode(10, "failed")
+	})
+
+	r.Add("client.sub", func(a *ws.Context) {
+		err := a.Client.Hub.UserLogin(a.Get("uid"), "app", a.Client)
+		if err != nil {
+			a.SendCode(11, err.Error())
+			return
+		}
+
+		a.Sub(a.Get("topic"))
+		a.SendOk()
+	})
+
+	r.Add("client.pub", func(a *ws.Context) {
+		a.Pub(a.Get("topic"), ws.H{"text": a.Get("text")})
+		a.SendOk()
+	})
+
+	r.Add("client.login", func(a *ws.Context) {
+		err := a.Client.Hub.UserLogin(a.Get("uid"), "app", a.Client)
+		if err != nil {
+			a.SendCode(11, err.Error())
+			return
+		}
+
+		a.SendOk()
+	})
+
+	r.Add("client.kick", func(a *ws.Context) {
+		a.Client.Conn.Close()
+	})
+}
+
+func TestClientCall(t *testing.T) {
+	url := newTestServer(t)
+
+	c, err := Dial(context.Background(), url)
+	require.NoError(t, err)
+	defer c.Close()
+
+	msg, err := c.Call(context.Background(), "client.echo", map[string]any{"name": "aqi"})
+	require.NoError(t, err)
+
+	var data struct {
+		Name string `json:"name"`
+	}
+	require.NoError(t, msg.Bind(&data))
+	require.Equal(t, "aqi", data.Name)
+
+	_, err = c.Call(context.Background(), "client.fail", nil)
+	var e *Error
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, 10, e.Code)
+
+	//不存在的action响应不带id
+	_, err = c.Call(context.Background(), "client.missing", nil)
+	require.ErrorAs(t, err, &e)
+	require.Equal(t, -1005, e.Code)
+}
+
+func TestClientPushAndTopic(t *testing.T) {
+	url := newTestServer(t)
+
+	c, err := Dial(context.Background(), url)
+	require.NoError(t, err)
+	defer c.Close()
+
+	notice := make(chan string, 1)
+	c.On("client.notice", func(msg *Message) {
+		var data struct {
+			Text string `json:"text"`
+		}
+		_ = msg.Bind(&data)
+		notice <- data.Text
+	})
+
+	_, err = c.Call(context.Background(), "client.push", map[string]any{"text": "hello"})
+	require.NoError(t, err)
+	require.Equal(t, "hello", receive(t, notice))
+
+	topic := make(chan string, 1)
+	err = c.Subscribe(context.Background(), "client.sub", map[string]any{"uid": "u1", "topic": "room"}, "room", func(msg *TopicMessage) {
+		var data struct {
+			Text string `json:"text"`
+		}
+		_ = msg.Bind(&data)
+		topic <- data.Text
+	})
+	require.NoError(t, err)
+
+	_, err = c.Call(context.Background(), "client.pub", map[string]any{"topic": "room", "text": "hi"})
+	require.NoError(t, err)
+	require.Equal(t, "hi", receive(t, topic))
+}
+
+func TestClientReconnect(t *testing.T) {
+	url := newTestServer(t)
+
+	var connected atomic.Int32
+	disconnected := make(chan error, 1)
+	c, err := Dial(context.Background(), url,
+		WithBackoff(10*time.Millisecond, 50*time.Millisecond),
+		WithOnConnect(func(c *Client) {
+			connected.Add(1)
+		}),
+		WithOnDisconnect(func(c *Client, err error) {
+			disconnected <- err
+		}),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.NoError(t, c.Send(context.Background(), "client.kick", nil))
+	receive(t, disconnected)
+
+	msg, err := c.Call(context.Background(), "client.echo", map[string]any{"name": "again"})
+	require.NoError(t, err)
+	require.Contains(t, string(msg.Data), "again")
+	require.Eventually(t, func() bool {
+		return connected.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, c.Close())
+	_, err = c.Call(context.Background(), "client.echo", nil)
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func receive[T any](t *testing.T, ch chan T) T {
+	t.Helper()
+
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	var zero T
+	return zero
+}
+
+func TestClientKicked(t *testing.T) {
+	url := newTestServer(t)
+
+	disconnected := make(chan error, 1)
+	first, err := Dial(context.Background(), url, WithOnDisconnect(func(c *Client, err error) {
+		disconnected <- err
+	}))
+	require.NoError(t, err)
+	defer first.Close()
+
+	_, err = first.Call(context.Background(), "client.login", map[string]any{"uid": "kicked"})
+	require.NoError(t, err)
+
+	second, err := Dial(context.Background(), url)
+	require.NoError(t, err)
+	defer second.Close()
+
+	_, err = second.Call(context.Background(), "client.login", map[string]any{"uid": "kicked"})
+	require.NoError(t, err)
+
+	select {
+	case err = <-disconnected:
+		require.ErrorIs(t, err, ErrKicked)
+	case <-time.After(3 * time.Second):
+		t.Fatal("first client not kicked")
+	}
+
+	_, err = first.Call(context.Background(), "client.echo", nil)
+	require.ErrorIs(t, err, ErrKicked)
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrClosed       = errors.New("aqi client closed")
+	ErrDisconnected = errors.New("aqi client disconnected")
+	ErrKicked       = errors.New("aqi client kicked by another login")
+)
+
+// Message 服务端消息，对应 ws.Action
+type Message struct {
+	Code   int             `json:"code"`
+	Action string          `json:"action"`
+	Id     string          `json:"id,omitempty"`
+	Msg    string          `json:"msg,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// Bind 解析 data
+func (m *Message) Bind(v any) error {
+	if len(m.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(m.Data, v)
+}
+
+// Err code 不为0时返回 *Error
+func (m *Message) Err() error {
+	if m.Code == 0 {
+		return nil
+	}
+
+	return &Error{Code: m.Code, Msg: m.Msg, Action: m.Action}
+}
+
+// TopicMessage 发布订阅消息，服务端以主题ID作为action推送
+type TopicMessage struct {
+	TopicId string          `json:"topicId"`
+	Message json.RawMessage `json:"message"`
+}
+
+// Bind 解析 message
+func (m *TopicMessage) Bind(v any) error {
+	return json.Unmarshal(m.Message, v)
+}
+
+// Error 服务端返回的错误
+type Error struct {
+	Code   int
+	Msg    string
+	Action string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: code %d, %s", e.Action, e.Code, e.Msg)
+}
+
+type request struct {
+	Id     string `json:"id,omitempty"`
+	Action string `json:"action"`
+	Params any    `json:"params,omitempty"`
+}
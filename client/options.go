@@ -0,0 +1,65 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+type Option func(*Client)
+
+// WithHeader 握手时携带的请求头，如 Authorization
+func WithHeader(header http.Header) Option {
+	return func(c *Client) {
+		c.header = header
+	}
+}
+
+// WithReconnect 断线后是否自动重连，默认开启
+func WithReconnect(enable bool) Option {
+	return func(c *Client) {
+		c.reconnect = enable
+	}
+}
+
+// WithBackoff 重连等待时间，从 min 开始每次翻倍直到 max
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Client) {
+		if min > 0 {
+			c.backoffMin = min
+		}
+
+		if max >= c.backoffMin {
+			c.backoffMax = max
+		}
+	}
+}
+
+// WithPingInterval 定时发送 ping action，0 表示只回应服务端的 ping 帧
+func WithPingInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.pingInterval = d
+	}
+}
+
+// WithTimeout Call 未设置截止时间时使用的超时时间
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
+
+// WithOnConnect 每次连接（含重连）成功后回调，可用于登录和恢复订阅
+func WithOnConnect(fn func(c *Client)) Option {
+	return func(c *Client) {
+		c.onConnect = fn
+	}
+}
+
+// WithOnDisconnect 连接断开时回调
+func WithOnDisconnect(fn func(c *Client, err error)) Option {
+	return func(c *Client) {
+		c.onDisconnect = fn
+	}
+}
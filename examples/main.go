@@ -9,6 +9,7 @@ import (
 	"github.com/wonli/aqi"
 	"github.com/wonli/aqi/mcp"
 	"github.com/wonli/aqi/middlewares"
+	"github.com/wonli/aqi/stats"
 	"github.com/wonli/aqi/ws"
 )
 
@@ -44,21 +45,76 @@ func main() {
 		mcp.WithVersion("0.1.0"),
 	)
 
+	type nowResponse struct {
+		Unix int64  `json:"unix" validate:"required"`
+		Time string `json:"time" validate:"required" label:"RFC 3339 time"`
+	}
+
 	mcpServer.Tool("time.now", mcp.Tool{
-		Description: "Get current server time.",
-		InputSchema: mcp.EmptyObjectSchema(),
-		Policy:      mcp.ToolPolicy{ReadOnly: true},
+		Description:  "Get current server time.",
+		InputSchema:  mcp.EmptyObjectSchema(),
+		OutputSchema: mcp.SchemaFor[nowResponse](),
+		Policy:       mcp.ToolPolicy{ReadOnly: true},
 		Handler: func(ctx *mcp.Context) {
-			ctx.Send(struct {
-				Unix int64  `json:"unix"`
-				Time string `json:"time"`
-			}{
+			ctx.Send(nowResponse{
 				Unix: time.Now().Unix(),
 				Time: time.Now().Format(time.RFC3339),
 			})
 		},
 	})
 
+	type formatRequest struct {
+		Unix   int64  `json:"unix" validate:"required,min=0" label:"Unix timestamp"`
+		Layout string `json:"layout,omitempty" validate:"omitempty,oneof=rfc3339 date" label:"Output layout"`
+	}
+
+	mcpServer.Tool("time.format", mcp.Tool{
+		Description: "Format a unix timestamp.",
+		InputSchema: mcp.SchemaFor[formatRequest](),
+		Policy:      mcp.ToolPolicy{ReadOnly: true},
+		Handler: func(ctx *mcp.Context) {
+			var req formatRequest
+			if err := ctx.BindingValidateJson(&req); err != nil {
+				ctx.Error(err)
+				return
+			}
+
+			layout := time.RFC3339
+			if req.Layout == "date" {
+				layout = time.DateOnly
+			}
+
+			ctx.Send(time.Unix(req.Unix, 0).Format(layout))
+		},
+	})
+
+	mcpServer.Resource("stats://history", mcp.Resource{
+		Description: "Recent runtime stats.",
+		MimeType:    "application/json",
+		Handler: func(ctx *mcp.Context) {
+			ctx.Send(stats.InitStatsCollector().GetStats())
+		},
+	})
+
+	mcpServer.Prompt("stats.review", mcp.Prompt{
+		Description: "Review recent runtime stats.",
+		Arguments: mcp.ObjectSchema(map[string]mcp.Schema{
+			"focus": mcp.StringSchema("What to focus on, e.g. memory"),
+		}),
+		Handler: func(ctx *mcp.Context) {
+			contents, err := ctx.Resource("stats://history")
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+
+			ctx.Send([]mcp.PromptMessage{
+				mcp.UserMessage("Review these stats and point out anomalies in " + ctx.Get("focus") + "."),
+				mcp.ResourceMessage(mcp.RoleUser, contents[0]),
+			})
+		},
+	})
+
 	engine.Any("/mcp", gin.WrapH(mcpServer.HTTPHandler()))
 
 	app.WithHttpServer(engine)
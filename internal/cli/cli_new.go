@@ -133,12 +133,14 @@ func projectTemplates() []struct {
 		{"templates/default/cmd/api.go.tmpl", "cmd/api.go"},
 		{"templates/default/cmd/dal.go.tmpl", "cmd/dal.go"},
 		{"templates/default/cmd/boot.go.tmpl", "cmd/boot.go"},
+		{"templates/default/cmd/mcp.go.tmpl", "cmd/mcp.go"},
 		{"templates/default/dbc/dbc.go.tmpl", "internal/dbc/dbc.go"},
 		{"templates/default/middlewares/app.go.tmpl", "internal/middlewares/app.go"},
 		{"templates/default/middlewares/recovery.go.tmpl", "internal/middlewares/recovery.go"},
 		{"templates/default/middlewares/cors.go.tmpl", "internal/middlewares/cors.go"},
 		{"templates/default/router/action.go.tmpl", "internal/router/action.go"},
 		{"templates/default/router/api.go.tmpl", "internal/router/api.go"},
+		{"templates/default/router/mcp.go.tmpl", "internal/router/mcp.go"},
 	}
 }
 
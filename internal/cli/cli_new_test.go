@@ -74,3 +74,37 @@ func TestCreateProjectIncludesGitignoreTemplate(t *testing.T) {
 		t.Fatal("project templates do not include .gitignore output")
 	}
 }
+
+func TestCreateProjectIncludesMcpCommand(t *testing.T) {
+	outputs := map[string]string{}
+	for _, tmpl := range projectTemplates() {
+		outputs[tmpl.outputPath] = tmpl.templatePath
+	}
+
+	for _, output := range []string{"cmd/mcp.go", "internal/router/mcp.go"} {
+		if _, ok := outputs[output]; !ok {
+			t.Fatalf("project templates do not include %s", output)
+		}
+	}
+
+	tmplContent, err := os.ReadFile(outputs["cmd/mcp.go"])
+	if err != nil {
+		t.Fatalf("read mcp template: %v", err)
+	}
+
+	tmpl, err := template.New("mcp.go").Parse(string(tmplContent))
+	if err != nil {
+		t.Fatalf("parse mcp template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newProjectTemplateData("b", "github.com/a/b")); err != nil {
+		t.Fatalf("execute mcp template: %v", err)
+	}
+
+	for _, want := range []string{"ServeStdio(ctx, os.Stdin, stdout)", `mcp.WithName("b")`, "github.com/a/b/internal/router"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("mcp command missing %q:\n%s", want, buf.String())
+		}
+	}
+}
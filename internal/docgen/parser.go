@@ -166,6 +166,26 @@ func ParseRouterFile(filePath, funcName string) ([]ActionDoc, error) {
 	return actions, nil
 }
 
+// ParseRouterDir 扫描并解析目录中的全部路由文件，返回所有 action
+func ParseRouterDir(routerDir string) ([]ActionDoc, error) {
+	routerFiles, err := ScanRouterFiles(routerDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []ActionDoc
+	for _, rf := range routerFiles {
+		list, err := ParseRouterFile(filepath.Join(routerDir, rf.FileName), rf.FuncName)
+		if err != nil {
+			continue
+		}
+
+		actions = append(actions, list...)
+	}
+
+	return actions, nil
+}
+
 // buildMiddlewareMap 构建中间件链映射
 func buildMiddlewareMap(routerFunc *ast.FuncDecl, file *ast.File) map[string][]string {
 	middlewareMap := make(map[string][]string)
@@ -0,0 +1,276 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WellKnownProtectedResource is the path prefix of the OAuth protected
+// resource metadata (RFC 9728).
+const WellKnownProtectedResource = "/.well-known/oauth-protected-resource"
+
+// OAuth makes the MCP endpoint an OAuth 2.1 resource server: requests must
+// carry a JWT access token issued by Issuer for Resource, verified with a
+// local JWKS file or key. The authorization server itself is not part of
+// aqi.
+type OAuth struct {
+	// Resource is the canonical URI of the MCP endpoint, e.g.
+	// https://api.example.com/mcp. Tokens must name it in aud unless
+	// Audience is set.
+	Resource string
+
+	// AuthorizationServers are advertised in the resource metadata so
+	// clients know where to get tokens, usually just Issuer.
+	AuthorizationServers []string
+
+	Issuer   string
+	Audience string
+
+	// JWKSFile is a JWK set on disk. It is read again when a token is signed
+	// with an unknown kid, so keys can be rotated by replacing the file.
+	JWKSFile string
+
+	// Key is a local verification key: *rsa.PublicKey, *ecdsa.PublicKey,
+	// ed25519.PublicKey or a []byte HMAC secret.
+	Key any
+
+	// Scopes are required for every request, tools can require more with
+	// ToolPolicy.Scopes. All of them are advertised in the metadata.
+	Scopes []string
+
+	// Leeway tolerates clock skew when checking exp and nbf.
+	Leeway time.Duration
+
+	// MetadataURL is where the resource metadata is served, by default
+	// WellKnownProtectedResource inserted before the path of Resource.
+	MetadataURL string
+}
+
+// Principal is who made a request: the subject and scopes of the access
+// token when the server uses OAuth, and the client address.
+type Principal struct {
+	Subject  string
+	ClientId string
+	Scopes   []string
+	Claims   map[string]any // all claims of the access token
+	Address  string         // the remote address, or X-Forwarded-For behind a trusted proxy
+}
+
+// Authenticated reports whether the request carried a valid access token.
+func (p Principal) Authenticated() bool {
+	return p.Claims != nil
+}
+
+// HasScope reports whether the access token grants all scopes.
+func (p Principal) HasScope(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(p.Scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns the subject, or the address for anonymous requests.
+func (p Principal) String() string {
+	if p.Subject != "" {
+		return p.Subject
+	}
+
+	return p.Address
+}
+
+type principalKey struct{}
+
+type oauthServer struct {
+	OAuth
+	verifier *tokenVerifier
+}
+
+type protectedResourceMetadata struct {
+	Resource               string   `json:"resource"`
+	AuthorizationServers   []string `json:"authorization_servers,omitempty"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported"`
+}
+
+// WithOAuth validates access tokens on every HTTP request, see OAuth. Use it
+// instead of WithBearerToken; a WithAuth check still runs after it. Serve
+// MetadataHandler at OAuth.MetadataURL so clients can discover the
+// authorization server:
+//
+//	engine.GET("/.well-known/oauth-protected-resource/mcp", gin.WrapH(server.MetadataHandler()))
+//
+// WithOAuth panics when the config has no resource, issuer or usable key.
+func WithOAuth(config OAuth) Option {
+	return func(s *Server) {
+		if config.Resource == "" || config.Issuer == "" {
+			panic(fmt.Errorf("%w: resource and issuer are required", ErrInvalidOAuth))
+		}
+
+		verifier, err := newTokenVerifier(config)
+		if err != nil {
+			panic(err)
+		}
+
+		if config.MetadataURL == "" {
+			config.MetadataURL = metadataURL(config.Resource)
+		}
+		if len(config.AuthorizationServers) == 0 {
+			config.AuthorizationServers = []string{config.Issuer}
+		}
+
+		s.oauth = &oauthServer{OAuth: config, verifier: verifier}
+	}
+}
+
+// MetadataHandler serves the OAuth protected resource metadata. It answers
+// 404 when the server does not use WithOAuth.
+func (s *Server) MetadataHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.oauth == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentTypeJSON)
+		_ = json.NewEncoder(w).Encode(protectedResourceMetadata{
+			Resource:               s.oauth.Resource,
+			AuthorizationServers:   s.oauth.AuthorizationServers,
+			ScopesSupported:        s.scopesSupported(),
+			BearerMethodsSupported: []string{"header"},
+		})
+	})
+}
+
+func (s *Server) scopesSupported() []string {
+	scopes := slices.Clone(s.oauth.Scopes)
+
+	s.mu.RLock()
+	for _, tool := range s.tools {
+		scopes = append(scopes, tool.Policy.Scopes...)
+	}
+	s.mu.RUnlock()
+
+	slices.Sort(scopes)
+	return slices.Compact(scopes)
+}
+
+// authenticate validates the access token and returns r carrying the
+// principal. On failure it writes the challenge and returns nil.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) *http.Request {
+	value := r.Header.Get("Authorization")
+	scheme, token, _ := strings.Cut(value, " ")
+	if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		s.challenge(w, http.StatusUnauthorized, "", "", s.oauth.Scopes)
+		return nil
+	}
+
+	claims, err := s.oauth.verifier.verify(strings.TrimSpace(token))
+	if err != nil {
+		logInfof("mcp oauth error=%s", err.Error())
+		s.challenge(w, http.StatusUnauthorized, "invalid_token", strings.TrimPrefix(err.Error(), ErrInvalidToken.Error()+": "), nil)
+		return nil
+	}
+
+	principal := Principal{
+		Subject: stringClaim(claims, "sub"),
+		Scopes:  strings.Fields(stringClaim(claims, "scope")),
+		Claims:  claims,
+		Address: s.requestAddress(r),
+	}
+	principal.ClientId = stringClaim(claims, "client_id")
+	if principal.ClientId == "" {
+		principal.ClientId = stringClaim(claims, "azp")
+	}
+	if len(principal.Scopes) == 0 {
+		principal.Scopes = claimStrings(claims["scp"])
+	}
+
+	if !principal.HasScope(s.oauth.Scopes...) {
+		s.challenge(w, http.StatusForbidden, "insufficient_scope", "", s.oauth.Scopes)
+		return nil
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, principal))
+}
+
+// challenge answers with a WWW-Authenticate header pointing the client at
+// the resource metadata, as required by the MCP authorization spec.
+func (s *Server) challenge(w http.ResponseWriter, status int, code, description string, scopes []string) {
+	params := []string{fmt.Sprintf("resource_metadata=%q", s.oauth.MetadataURL)}
+	if code != "" {
+		params = append(params, fmt.Sprintf("error=%q", code))
+	}
+	if description != "" {
+		params = append(params, fmt.Sprintf("error_description=%q", description))
+	}
+	if len(scopes) > 0 {
+		params = append(params, fmt.Sprintf("scope=%q", strings.Join(scopes, " ")))
+	}
+
+	w.Header().Set("WWW-Authenticate", "Bearer "+strings.Join(params, ", "))
+	http.Error(w, http.StatusText(status), status)
+}
+
+// authorizeTool checks the scopes a tool requires. Requests without a
+// principal, such as stdio, are not checked.
+func (s *Server) authorizeTool(x *exchange, tool registeredTool) *rpcError {
+	if s.oauth == nil || len(tool.Policy.Scopes) == 0 || x.r == nil {
+		return nil
+	}
+
+	principal, ok := x.r.Context().Value(principalKey{}).(Principal)
+	if !ok || principal.HasScope(tool.Policy.Scopes...) {
+		return nil
+	}
+
+	scopes := slices.Concat(s.oauth.Scopes, tool.Policy.Scopes)
+	return &rpcError{Code: rpcErrorInsufficientScope, Message: ErrInsufficientScope.Error(), Data: scopeError{Scopes: scopes}}
+}
+
+type scopeError struct {
+	Scopes []string `json:"scopes"`
+}
+
+// scopeChallenge turns the insufficient scope error of a single tools/call
+// into a 403 challenge, so the client can ask the user for more scopes.
+func (s *Server) scopeChallenge(w http.ResponseWriter, res any) bool {
+	single, ok := res.(rpcResponse)
+	if !ok || single.Error == nil {
+		return false
+	}
+
+	data, ok := single.Error.Data.(scopeError)
+	if !ok {
+		return false
+	}
+
+	s.challenge(w, http.StatusForbidden, "insufficient_scope", "", data.Scopes)
+	return true
+}
+
+// metadataURL inserts the well-known path before the path of resource.
+func metadataURL(resource string) string {
+	u, err := url.Parse(resource)
+	if err != nil || u.Host == "" {
+		return WellKnownProtectedResource
+	}
+
+	u.Path = WellKnownProtectedResource + strings.TrimSuffix(u.Path, "/")
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+func stringClaim(claims map[string]any, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
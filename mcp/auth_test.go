@@ -0,0 +1,294 @@
+package mcp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	testIssuer   = "https://auth.example.com"
+	testResource = "https://api.example.com/mcp"
+)
+
+func TestOAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwks, "k1", &key.PublicKey)
+
+	server := NewServer(nil, WithOAuth(OAuth{
+		Resource: testResource,
+		Issuer:   testIssuer,
+		JWKSFile: jwks,
+		Scopes:   []string{"mcp"},
+	}))
+	server.Tool("whoami", Tool{
+		InputSchema: EmptyObjectSchema(),
+		Handler: func(ctx *Context) {
+			ctx.Send(ctx.Caller().Subject)
+		},
+	})
+	server.Tool("order.delete", Tool{
+		InputSchema: EmptyObjectSchema(),
+		Policy:      ToolPolicy{Scopes: []string{"orders:write"}},
+		Handler: func(ctx *Context) {
+			ctx.SendOk()
+		},
+	})
+
+	recorder := httptest.NewRecorder()
+	server.MetadataHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, WellKnownProtectedResource+"/mcp", nil))
+	require.Equal(t, testResource, gjson.Get(recorder.Body.String(), "resource").String())
+	require.Equal(t, testIssuer, gjson.Get(recorder.Body.String(), "authorization_servers.0").String())
+	require.Equal(t, `["mcp","orders:write"]`, gjson.Get(recorder.Body.String(), "scopes_supported").Raw)
+
+	call := func(token, tool string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodToolsCall, Params: toolCallParams{Name: tool}})
+		require.NoError(t, err)
+
+		request := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(data))
+		request.Header.Set("Content-Type", contentTypeJSON)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.HTTPHandler().ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	claims := func(edit func(map[string]any)) map[string]any {
+		c := map[string]any{
+			"iss":   testIssuer,
+			"aud":   []string{testResource},
+			"sub":   "user-1",
+			"scope": "mcp",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		if edit != nil {
+			edit(c)
+		}
+
+		return c
+	}
+
+	res := call("", "whoami")
+	require.Equal(t, http.StatusUnauthorized, res.Code)
+	require.Equal(t, `Bearer resource_metadata="https://api.example.com/.well-known/oauth-protected-resource/mcp", scope="mcp"`, res.Header().Get("WWW-Authenticate"))
+
+	for name, edit := range map[string]func(map[string]any){
+		"issuer":   func(c map[string]any) { c["iss"] = "https://evil.example.com" },
+		"audience": func(c map[string]any) { c["aud"] = "https://other.example.com" },
+		"expired":  func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"nbf":      func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"no exp":   func(c map[string]any) { delete(c, "exp") },
+	} {
+		res = call(signRS256(t, key, "k1", claims(edit)), "whoami")
+		require.Equal(t, http.StatusUnauthorized, res.Code, name)
+		require.Contains(t, res.Header().Get("WWW-Authenticate"), `error="invalid_token"`, name)
+	}
+
+	// Tokens that are not signed by a known key.
+	valid := strings.Split(signRS256(t, key, "k1", claims(nil)), ".")
+	tampered := jwtSigningInput(t, map[string]any{"alg": "RS256", "kid": "k1"}, claims(func(c map[string]any) { c["sub"] = "admin" }))
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})
+
+	for name, token := range map[string]string{
+		"tampered": tampered + "." + valid[2],
+		"none":     jwtSigningInput(t, map[string]any{"alg": "none"}, claims(nil)) + ".",
+		"hs256":    signHS256(t, publicPEM, map[string]any{"alg": "HS256", "kid": "k1"}, claims(nil)),
+		"hs256der": signHS256(t, public, map[string]any{"alg": "HS256", "kid": "k1"}, claims(nil)),
+	} {
+		res = call(token, "whoami")
+		require.Equal(t, http.StatusUnauthorized, res.Code, name)
+		require.Contains(t, res.Header().Get("WWW-Authenticate"), `error="invalid_token"`, name)
+	}
+
+	res = call(signRS256(t, key, "k1", claims(func(c map[string]any) { c["scope"] = "orders:read" })), "whoami")
+	require.Equal(t, http.StatusForbidden, res.Code)
+
+	token := signRS256(t, key, "k1", claims(nil))
+	res = call(token, "whoami")
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "user-1", gjson.Get(res.Body.String(), "result.structuredContent").String())
+
+	res = call(token, "order.delete")
+	require.Equal(t, http.StatusForbidden, res.Code)
+	require.Contains(t, res.Header().Get("WWW-Authenticate"), `error="insufficient_scope", scope="mcp orders:write"`)
+
+	res = call(signRS256(t, key, "k1", claims(func(c map[string]any) { c["scope"] = "mcp orders:write" })), "order.delete")
+	require.Equal(t, http.StatusOK, res.Code)
+	require.False(t, gjson.Get(res.Body.String(), "result.isError").Bool())
+
+	// Keys are rotated by replacing the JWKS file.
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeJWKS(t, jwks, "k2", &rotated.PublicKey)
+	require.NoError(t, os.Chtimes(jwks, time.Now(), time.Now().Add(time.Second)))
+
+	res = call(signRS256(t, rotated, "k2", claims(nil)), "whoami")
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, http.StatusUnauthorized, call(token, "whoami").Code)
+}
+
+func TestOAuthSessionSubject(t *testing.T) {
+	secret := []byte("secret")
+	server := NewServer(nil, WithOAuth(OAuth{Resource: testResource, Issuer: testIssuer, Key: secret}))
+
+	token := func(subject string) string {
+		return signHS256(t, secret, map[string]any{"alg": "HS256"}, map[string]any{
+			"iss": testIssuer,
+			"aud": testResource,
+			"sub": subject,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+	}
+
+	send := func(method, subject, id string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		request := httptest.NewRequest(method, "/mcp", bytes.NewReader(data))
+		request.Header.Set("Content-Type", contentTypeJSON)
+		request.Header.Set("Accept", contentTypeJSON+", "+contentTypeEventStream)
+		request.Header.Set("Authorization", "Bearer "+token(subject))
+		if id != "" {
+			request.Header.Set(headerSessionId, id)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.HTTPHandler().ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	res := send(http.MethodPost, "user-1", "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	id := res.Header().Get(headerSessionId)
+	require.NotEmpty(t, id)
+
+	ping := rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 2, Method: methodPing}
+	require.Equal(t, http.StatusOK, send(http.MethodPost, "user-1", id, ping).Code)
+
+	// Another subject can not use or end the session, even with its id.
+	require.Equal(t, http.StatusNotFound, send(http.MethodPost, "user-2", id, ping).Code)
+	require.Equal(t, http.StatusNotFound, send(http.MethodGet, "user-2", id, nil).Code)
+	require.Equal(t, http.StatusNotFound, send(http.MethodDelete, "user-2", id, nil).Code)
+
+	require.Equal(t, http.StatusNoContent, send(http.MethodDelete, "user-1", id, nil).Code)
+}
+
+func TestOAuthBatchScopes(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	server := NewServer(nil, WithOAuth(OAuth{Resource: testResource, Issuer: testIssuer, Key: &key.PublicKey}))
+	server.Tool("order.delete", Tool{
+		InputSchema: EmptyObjectSchema(),
+		Policy:      ToolPolicy{Scopes: []string{"orders:write"}},
+		Handler: func(ctx *Context) {
+			ctx.SendOk()
+		},
+	})
+
+	token := signES256(t, key, map[string]any{"iss": testIssuer, "aud": testResource, "sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	body, status := postRawRPC(t, server, "Bearer "+token, `[
+		{"jsonrpc":"2.0","id":1,"method":"ping"},
+		{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"order.delete"}}
+	]`)
+
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(rpcErrorInsufficientScope), gjson.Get(body, "1.error.code").Int())
+	require.Equal(t, `["orders:write"]`, gjson.Get(body, "1.error.data.scopes").Raw)
+}
+
+func TestOAuthInvalidConfigPanics(t *testing.T) {
+	require.Panics(t, func() {
+		NewServer(nil, WithOAuth(OAuth{Resource: testResource, Issuer: testIssuer}))
+	})
+	require.Panics(t, func() {
+		NewServer(nil, WithOAuth(OAuth{Issuer: testIssuer, Key: []byte("secret")}))
+	})
+}
+
+func writeJWKS(t *testing.T, path, kid string, key *rsa.PublicKey) {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"keys": []map[string]any{{
+		"kty": "RSA",
+		"kid": kid,
+		"alg": "RS256",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+
+	signed := jwtSigningInput(t, map[string]any{"alg": "RS256", "kid": kid, "typ": "JWT"}, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+
+	signed := jwtSigningInput(t, map[string]any{"alg": "ES256", "typ": "JWT"}, claims)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signHS256(t *testing.T, secret []byte, header, claims map[string]any) string {
+	t.Helper()
+
+	signed := jwtSigningInput(t, header, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func jwtSigningInput(t *testing.T, header, claims map[string]any) string {
+	t.Helper()
+
+	h, err := json.Marshal(header)
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+}
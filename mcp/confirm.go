@@ -0,0 +1,355 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/wonli/aqi/logger"
+	"github.com/wonli/aqi/utils"
+)
+
+// DefaultConfirmTimeout is how long a confirmation token stays valid and how
+// long the server waits for an elicitation answer.
+const DefaultConfirmTimeout = 5 * time.Minute
+
+// confirmationTokenArgument is the argument a client may use to pass the
+// token when it cannot set _meta.confirmationToken.
+const confirmationTokenArgument = "confirmationToken"
+
+// ConfirmDecision is an Approver's answer for a call that needs confirmation.
+type ConfirmDecision int
+
+const (
+	// ConfirmAsk asks the user, through elicitation/create when the client
+	// supports it or with a confirmation token otherwise.
+	ConfirmAsk ConfirmDecision = iota
+	ConfirmApprove
+	ConfirmDeny
+)
+
+// ConfirmRequest describes a tool call that needs confirmation.
+type ConfirmRequest struct {
+	Tool      string
+	Arguments json.RawMessage
+	Caller    string
+	Session   string
+	Transport string // TransportHTTP or TransportStdio
+}
+
+// Approver decides calls of tools whose policy is Destructive or
+// RequiresConfirmation before the user is asked, e.g. to approve trusted
+// callers or to deny calls outside office hours.
+type Approver func(ctx context.Context, req ConfirmRequest) ConfirmDecision
+
+// Confirmation methods and statuses recorded in AuditRecord.
+const (
+	ConfirmByApprover    = "approver"
+	ConfirmByToken       = "token"
+	ConfirmByElicitation = "elicitation"
+
+	ConfirmPending  = "pending"
+	ConfirmApproved = "approved"
+	ConfirmDenied   = "denied"
+	ConfirmDeclined = "declined"
+	ConfirmInvalid  = "invalid"
+)
+
+// AuditRecord is an entry of the confirmation audit trail: who asked for or
+// confirmed which call, and how.
+type AuditRecord struct {
+	Time      time.Time       `json:"time"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments"`
+	Caller    string          `json:"caller"`
+	Session   string          `json:"session,omitempty"`
+	Transport string          `json:"transport"`
+	Token     string          `json:"token,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Status    string          `json:"status"`
+}
+
+type AuditFunc func(record AuditRecord)
+
+// WithApprover sets the hook deciding calls that need confirmation. Without
+// one every such call asks the user.
+func WithApprover(approver Approver) Option {
+	return func(s *Server) {
+		s.approver = approver
+	}
+}
+
+// WithAudit receives the confirmation audit trail. By default records are
+// written to the application log.
+func WithAudit(fn AuditFunc) Option {
+	return func(s *Server) {
+		s.audit = fn
+	}
+}
+
+// WithConfirmTimeout sets how long confirmation tokens and elicitation
+// requests stay valid, 5 minutes by default.
+func WithConfirmTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		if timeout > 0 {
+			s.confirmTimeout = timeout
+		}
+	}
+}
+
+type pendingConfirmation struct {
+	tool      string
+	arguments any
+	session   string
+	caller    string
+	transport string
+	expires   time.Time
+}
+
+type confirmationResult struct {
+	Status    string    `json:"status"`
+	Token     string    `json:"confirmationToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (t Tool) needsConfirmation() bool {
+	return t.Policy.Destructive || t.Policy.RequiresConfirmation
+}
+
+// confirm runs the confirmation flow for a tool call. It returns false with
+// the result to send when the handler must not run yet.
+//
+// A call is approved by the Approver, by a valid token from an earlier
+// pending result, or by the user accepting an elicitation/create request.
+// Otherwise the call is answered with a pending result carrying a new token.
+func (s *Server) confirm(x *exchange, tool registeredTool, args json.RawMessage, token string) (toolResult, bool) {
+	record := AuditRecord{
+		Time:      time.Now(),
+		Tool:      tool.name,
+		Arguments: args,
+		Caller:    requestPrincipal(x.r).String(),
+		Session:   sessionId(x),
+		Transport: x.transport,
+	}
+
+	decision := ConfirmAsk
+	if s.approver != nil {
+		decision = s.approver(x.ctx, ConfirmRequest{
+			Tool:      record.Tool,
+			Arguments: args,
+			Caller:    record.Caller,
+			Session:   record.Session,
+			Transport: record.Transport,
+		})
+	}
+
+	switch decision {
+	case ConfirmApprove:
+		s.record(record, ConfirmByApprover, ConfirmApproved)
+		return toolResult{}, true
+	case ConfirmDeny:
+		s.record(record, ConfirmByApprover, ConfirmDenied)
+		return errorToolResult(ErrConfirmationDenied), false
+	}
+
+	if token != "" {
+		record.Token = token
+		if !s.redeem(x, tool.name, args, token) {
+			s.record(record, ConfirmByToken, ConfirmInvalid)
+			return errorToolResult(ErrInvalidConfirmation), false
+		}
+
+		s.record(record, ConfirmByToken, ConfirmApproved)
+		return toolResult{}, true
+	}
+
+	if accepted, err := s.elicitConfirmation(x, tool.name, args); err == nil {
+		if !accepted {
+			s.record(record, ConfirmByElicitation, ConfirmDeclined)
+			return errorToolResult(ErrConfirmationDeclined), false
+		}
+
+		s.record(record, ConfirmByElicitation, ConfirmApproved)
+		return toolResult{}, true
+	}
+
+	pending := s.issue(x, tool.name, args)
+	record.Token = pending.Token
+	s.record(record, ConfirmByToken, ConfirmPending)
+
+	result := toolResult{Content: []Content{TextContent(fmt.Sprintf(
+		"%s needs the user's confirmation. Ask the user to approve this call, then call %s again with the same arguments and confirmationToken %q.",
+		tool.name, tool.name, pending.Token,
+	))}}
+	if tool.OutputSchema.Type == "" {
+		result.StructuredContent = pending
+	}
+
+	return result, false
+}
+
+// issue stores a pending confirmation and returns its token.
+func (s *Server) issue(x *exchange, tool string, args json.RawMessage) confirmationResult {
+	now := time.Now()
+	pending := pendingConfirmation{
+		tool:      tool,
+		arguments: normalizeJSON(args),
+		session:   sessionId(x),
+		caller:    confirmationCaller(x),
+		transport: x.transport,
+		expires:   now.Add(s.confirmTimeout),
+	}
+
+	token := utils.GetRandomString(32)
+
+	s.confirmMu.Lock()
+	for key, p := range s.confirmations {
+		if now.After(p.expires) {
+			delete(s.confirmations, key)
+		}
+	}
+	s.confirmations[token] = pending
+	s.confirmMu.Unlock()
+
+	return confirmationResult{Status: ConfirmPending, Token: token, ExpiresAt: pending.expires}
+}
+
+// redeem consumes a token. It must belong to the same caller, transport,
+// session, tool and arguments it was issued for, so a confirmation can not be
+// reused for a different call. Stateless callers share no session, the
+// caller keeps their tokens apart, and another caller can not burn them.
+func (s *Server) redeem(x *exchange, tool string, args json.RawMessage, token string) bool {
+	s.confirmMu.Lock()
+	pending, ok := s.confirmations[token]
+	ok = ok && pending.caller == confirmationCaller(x) && pending.transport == x.transport
+	if ok {
+		delete(s.confirmations, token)
+	}
+	s.confirmMu.Unlock()
+
+	return ok && time.Now().Before(pending.expires) &&
+		pending.tool == tool && pending.session == sessionId(x) &&
+		reflect.DeepEqual(pending.arguments, normalizeJSON(args))
+}
+
+// confirmationCaller identifies who a token is issued to: the OAuth subject,
+// otherwise the client host, since a retry may come on a new connection.
+func confirmationCaller(x *exchange) string {
+	principal := requestPrincipal(x.r)
+	if principal.Subject != "" {
+		return principal.Subject
+	}
+
+	if host, _, err := net.SplitHostPort(principal.Address); err == nil {
+		return host
+	}
+
+	return principal.Address
+}
+
+// elicitConfirmation asks the user through elicitation/create. It fails when
+// the client did not declare elicitation or no stream can carry the request.
+func (s *Server) elicitConfirmation(x *exchange, tool string, args json.RawMessage) (bool, error) {
+	if x.session == nil {
+		return false, ErrStreamUnavailable
+	}
+
+	x.session.mu.Lock()
+	supported := x.session.elicitation
+	x.session.mu.Unlock()
+	if !supported {
+		return false, ErrStreamUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(x.ctx, s.confirmTimeout)
+	defer cancel()
+
+	ex := *x
+	ex.ctx = ctx
+
+	var res struct {
+		Action string `json:"action"`
+	}
+	err := s.request(&ex, methodElicitationCreate, elicitParams{
+		Message:         fmt.Sprintf("Allow %s to run with arguments %s?", tool, compactJSON(args)),
+		RequestedSchema: EmptyObjectSchema(),
+	}, &res)
+	if err != nil {
+		return false, err
+	}
+
+	return res.Action == "accept", nil
+}
+
+type elicitParams struct {
+	Message         string `json:"message"`
+	RequestedSchema Schema `json:"requestedSchema"`
+}
+
+func (s *Server) record(record AuditRecord, method, status string) {
+	record.Method = method
+	record.Status = status
+
+	if s.audit != nil {
+		s.audit(record)
+		return
+	}
+
+	if logger.SugarLog != nil {
+		logger.SugarLog.Infow("mcp confirmation", "tool", record.Tool, "status", record.Status,
+			"method", record.Method, "caller", record.Caller, "session", record.Session, "transport", record.Transport,
+			"arguments", string(record.Arguments))
+	}
+}
+
+// confirmationToken takes the token out of _meta or the arguments, so the
+// handler and argument validation never see it.
+func confirmationToken(meta string, args json.RawMessage) (string, json.RawMessage) {
+	var values map[string]json.RawMessage
+	if json.Unmarshal(args, &values) != nil {
+		return meta, args
+	}
+
+	raw, ok := values[confirmationTokenArgument]
+	if !ok {
+		return meta, args
+	}
+
+	delete(values, confirmationTokenArgument)
+	stripped, err := json.Marshal(values)
+	if err != nil {
+		return meta, args
+	}
+
+	if meta == "" {
+		_ = json.Unmarshal(raw, &meta)
+	}
+
+	return meta, stripped
+}
+
+// confirmationSchema advertises the token argument on tools that need
+// confirmation.
+func confirmationSchema(schema Schema) Schema {
+	properties := make(map[string]Schema, len(schema.Properties)+1)
+	for name, prop := range schema.Properties {
+		properties[name] = prop
+	}
+
+	properties[confirmationTokenArgument] = StringSchema("Token from a pending confirmation result. Omit it on the first call.")
+	schema.Properties = properties
+	return schema
+}
+
+func compactJSON(data json.RawMessage) string {
+	var buf bytes.Buffer
+	if json.Compact(&buf, data) != nil {
+		return string(data)
+	}
+
+	return buf.String()
+}
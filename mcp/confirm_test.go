@@ -0,0 +1,231 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func newDeleteServer(opts ...Option) (*Server, *int) {
+	deleted := 0
+	server := NewServer(nil, opts...)
+	server.Tool("order.delete", Tool{
+		InputSchema: SchemaFor[echoRequest](),
+		Policy:      ToolPolicy{Destructive: true},
+		Handler: func(ctx *Context) {
+			deleted++
+			ctx.Send(ctx.Get("text"))
+		},
+	})
+
+	return server, &deleted
+}
+
+func deleteCall(args map[string]any, token string) rpcRequestBody {
+	params := map[string]any{"name": "order.delete", "arguments": args}
+	if token != "" {
+		params["_meta"] = map[string]any{"confirmationToken": token}
+	}
+
+	return rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodToolsCall, Params: params}
+}
+
+func TestConfirmationToken(t *testing.T) {
+	var mu sync.Mutex
+	var records []AuditRecord
+	server, deleted := newDeleteServer(WithAudit(func(record AuditRecord) {
+		mu.Lock()
+		records = append(records, record)
+		mu.Unlock()
+	}))
+
+	body, _ := postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodToolsList})
+	require.Equal(t, "string", gjson.Get(body, "result.tools.0.inputSchema.properties.confirmationToken.type").String())
+
+	body, _ = postRPC(t, server, "", deleteCall(map[string]any{"text": "A1"}, ""))
+	require.False(t, gjson.Get(body, "result.isError").Bool())
+	require.Equal(t, ConfirmPending, gjson.Get(body, "result.structuredContent.status").String())
+	token := gjson.Get(body, "result.structuredContent.confirmationToken").String()
+	require.Len(t, token, 32)
+	require.Contains(t, gjson.Get(body, "result.content.0.text").String(), token)
+	require.Zero(t, *deleted)
+
+	// The token only confirms the call it was issued for.
+	body, _ = postRPC(t, server, "", deleteCall(map[string]any{"text": "B2"}, token))
+	require.True(t, gjson.Get(body, "result.isError").Bool())
+	require.Zero(t, *deleted)
+
+	body, _ = postRPC(t, server, "", deleteCall(map[string]any{"text": "A1"}, ""))
+	token = gjson.Get(body, "result.structuredContent.confirmationToken").String()
+
+	body, _ = postRPC(t, server, "", deleteCall(map[string]any{"text": "A1", "confirmationToken": token}, ""))
+	require.False(t, gjson.Get(body, "result.isError").Bool())
+	require.Equal(t, "A1", gjson.Get(body, "result.structuredContent").String())
+	require.Equal(t, 1, *deleted)
+
+	// Tokens are single-use.
+	body, _ = postRPC(t, server, "", deleteCall(map[string]any{"text": "A1"}, token))
+	require.Contains(t, gjson.Get(body, "result.content.0.text").String(), ErrInvalidConfirmation.Error())
+	require.Equal(t, 1, *deleted)
+
+	mu.Lock()
+	defer mu.Unlock()
+	statuses := make([]string, 0, len(records))
+	for _, record := range records {
+		statuses = append(statuses, record.Status)
+	}
+	require.Equal(t, []string{ConfirmPending, ConfirmInvalid, ConfirmPending, ConfirmApproved, ConfirmInvalid}, statuses)
+	require.Equal(t, "order.delete", records[3].Tool)
+	require.Equal(t, ConfirmByToken, records[3].Method)
+	require.JSONEq(t, `{"text":"A1"}`, string(records[3].Arguments))
+}
+
+func TestConfirmationTokenCaller(t *testing.T) {
+	secret := []byte("secret")
+	server, deleted := newDeleteServer(WithStateless(), WithOAuth(OAuth{Resource: testResource, Issuer: testIssuer, Key: secret}))
+
+	call := func(subject, token string) string {
+		auth := "Bearer " + signHS256(t, secret, map[string]any{"alg": "HS256"}, map[string]any{
+			"iss": testIssuer,
+			"aud": testResource,
+			"sub": subject,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+
+		body, status := postRPC(t, server, auth, deleteCall(map[string]any{"text": "A1"}, token))
+		require.Equal(t, http.StatusOK, status)
+		return body
+	}
+
+	body := call("user-1", "")
+	token := gjson.Get(body, "result.structuredContent.confirmationToken").String()
+	require.NotEmpty(t, token)
+
+	// Stateless callers share no session, the subject keeps their tokens apart.
+	body = call("user-2", token)
+	require.Contains(t, gjson.Get(body, "result.content.0.text").String(), ErrInvalidConfirmation.Error())
+	require.Zero(t, *deleted)
+
+	// The rejected attempt does not consume the token.
+	body = call("user-1", token)
+	require.Equal(t, "A1", gjson.Get(body, "result.structuredContent").String())
+	require.Equal(t, 1, *deleted)
+}
+
+func TestConfirmationApprover(t *testing.T) {
+	server, deleted := newDeleteServer(WithApprover(func(ctx context.Context, req ConfirmRequest) ConfirmDecision {
+		if gjson.GetBytes(req.Arguments, "text").String() == "keep" {
+			return ConfirmDeny
+		}
+
+		return ConfirmApprove
+	}))
+
+	body, _ := postRPC(t, server, "", deleteCall(map[string]any{"text": "A1"}, ""))
+	require.Equal(t, "A1", gjson.Get(body, "result.structuredContent").String())
+	require.Equal(t, 1, *deleted)
+
+	body, _ = postRPC(t, server, "", deleteCall(map[string]any{"text": "keep"}, ""))
+	require.True(t, gjson.Get(body, "result.isError").Bool())
+	require.Contains(t, gjson.Get(body, "result.content.0.text").String(), ErrConfirmationDenied.Error())
+	require.Equal(t, 1, *deleted)
+}
+
+func TestConfirmationAuditCaller(t *testing.T) {
+	audit := func(opts ...Option) func(forwarded string) AuditRecord {
+		records := make(chan AuditRecord, 1)
+		server, _ := newDeleteServer(append(opts, WithAudit(func(record AuditRecord) {
+			records <- record
+		}))...)
+
+		return func(forwarded string) AuditRecord {
+			data, err := json.Marshal(deleteCall(map[string]any{"text": "A1"}, ""))
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(data))
+			request.Header.Set("Content-Type", contentTypeJSON)
+			request.Header.Set("X-Forwarded-For", forwarded)
+			server.HTTPHandler().ServeHTTP(httptest.NewRecorder(), request)
+			return <-records
+		}
+	}
+
+	// X-Forwarded-For is ignored unless the request comes from a trusted proxy.
+	record := audit()("203.0.113.7")
+	require.Equal(t, "192.0.2.1:1234", record.Caller)
+	require.Equal(t, TransportHTTP, record.Transport)
+
+	call := audit(WithTrustedProxies("192.0.2.0/24", "10.0.0.1"))
+	require.Equal(t, "203.0.113.7", call("203.0.113.7").Caller)
+	require.Equal(t, "203.0.113.7", call("198.51.100.9, 203.0.113.7, 10.0.0.1").Caller)
+
+	require.Panics(t, func() { NewServer(nil, WithTrustedProxies("proxy")) })
+
+	// Stdio calls have no address, the transport tells them apart.
+	records := make(chan AuditRecord, 1)
+	server, _ := newDeleteServer(WithAudit(func(record AuditRecord) {
+		records <- record
+	}))
+
+	data, err := json.Marshal(deleteCall(map[string]any{"text": "A1"}, ""))
+	require.NoError(t, err)
+	require.NoError(t, server.ServeStdio(context.Background(), bytes.NewReader(append(data, '\n')), io.Discard))
+
+	record = <-records
+	require.Empty(t, record.Caller)
+	require.Equal(t, TransportStdio, record.Transport)
+}
+
+func TestConfirmationElicitation(t *testing.T) {
+	server, deleted := newDeleteServer()
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = server.ServeStdio(ctx, inReader, outWriter)
+	}()
+
+	lines := bufio.NewReader(outReader)
+	send := func(line string) {
+		_, err := io.WriteString(inWriter, line+"\n")
+		require.NoError(t, err)
+	}
+	read := func() string {
+		line, err := lines.ReadString('\n')
+		require.NoError(t, err)
+		return strings.TrimSpace(line)
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{"elicitation":{}}}}`)
+	read()
+
+	for _, c := range []struct {
+		action  string
+		deleted int
+	}{{"decline", 0}, {"accept", 1}} {
+		send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"order.delete","arguments":{"text":"A1"}}}`)
+
+		request := read()
+		require.Equal(t, methodElicitationCreate, gjson.Get(request, "method").String())
+		require.Contains(t, gjson.Get(request, "params.message").String(), "order.delete")
+		send(`{"jsonrpc":"2.0","id":` + gjson.Get(request, "id").Raw + `,"result":{"action":"` + c.action + `"}}`)
+
+		line := read()
+		require.Equal(t, int64(2), gjson.Get(line, "id").Int())
+		require.Equal(t, c.action != "accept", gjson.Get(line, "result.isError").Bool())
+		require.Equal(t, c.deleted, *deleted)
+	}
+}
@@ -5,6 +5,15 @@ const (
 	DefaultProtocolVersion = "2025-11-25"
 	DefaultServerName      = "aqi"
 	DefaultServerVersion   = "0.1.0"
+
+	// DefaultMaxBodySize bounds the body of a POST request, see WithMaxBodySize.
+	DefaultMaxBodySize = 4 << 20
+)
+
+// Transports a request can arrive on, recorded in AuditRecord.
+const (
+	TransportHTTP  = "http"
+	TransportStdio = "stdio"
 )
 
 const (
@@ -13,13 +22,45 @@ const (
 	methodInitialize               = "initialize"
 	methodNotificationsInitialized = "notifications/initialized"
 	methodPing                     = "ping"
+	methodNotificationsCancelled   = "notifications/cancelled"
+	methodNotificationsProgress    = "notifications/progress"
+	methodNotificationsMessage     = "notifications/message"
+	methodLoggingSetLevel          = "logging/setLevel"
 	methodToolsList                = "tools/list"
 	methodToolsCall                = "tools/call"
 
-	contentTypeJSON = "application/json"
-	contentTypeText = "text"
+	methodNotificationsToolsListChanged     = "notifications/tools/list_changed"
+	methodNotificationsResourcesListChanged = "notifications/resources/list_changed"
+	methodNotificationsPromptsListChanged   = "notifications/prompts/list_changed"
+
+	methodResourcesList                = "resources/list"
+	methodResourcesRead                = "resources/read"
+	methodResourcesTemplatesList       = "resources/templates/list"
+	methodResourcesSubscribe           = "resources/subscribe"
+	methodResourcesUnsubscribe         = "resources/unsubscribe"
+	methodNotificationsResourceUpdated = "notifications/resources/updated"
+
+	methodPromptsList = "prompts/list"
+	methodPromptsGet  = "prompts/get"
+
+	methodElicitationCreate = "elicitation/create"
+
+	contentTypeJSON     = "application/json"
+	contentTypeText     = "text"
+	contentTypeResource = "resource"
+
+	contentTypeImage        = "image"
+	contentTypeAudio        = "audio"
+	contentTypeResourceLink = "resource_link"
+
+	mimeTypeText   = "text/plain"
+	mimeTypeBinary = "application/octet-stream"
 
 	defaultEmptyArguments = "{}"
+
+	// validateLanguage picks the validator translations used by
+	// Context.BindingValidateJson; messages go to the model, so English.
+	validateLanguage = "en"
 )
 
 const (
@@ -27,4 +68,11 @@ const (
 	rpcErrorInvalidRequest = -32600
 	rpcErrorMethodNotFound = -32601
 	rpcErrorInvalidParams  = -32602
+	rpcErrorInternalError  = -32603
+
+	rpcErrorResourceNotFound = -32002
+
+	// rpcErrorInsufficientScope is answered with a 403 challenge when the
+	// transport allows it.
+	rpcErrorInsufficientScope = -32003
 )
@@ -0,0 +1,83 @@
+package mcp
+
+import "encoding/json"
+
+// Content is a typed content block of a tool result or prompt message: text,
+// an image, audio, an embedded resource or a link to a resource.
+type Content struct {
+	Type        string            `json:"type"`
+	Text        string            `json:"text,omitempty"`
+	Data        []byte            `json:"data,omitempty"` // image or audio bytes, base64 encoded on the wire
+	MimeType    string            `json:"mimeType,omitempty"`
+	Resource    *ResourceContents `json:"resource,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+func (c Content) MarshalJSON() ([]byte, error) {
+	if c.Type == contentTypeText {
+		return json.Marshal(struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}{Type: c.Type, Text: c.Text})
+	}
+
+	type content Content
+	return json.Marshal(content(c))
+}
+
+func TextContent(text string) Content {
+	return Content{Type: contentTypeText, Text: text}
+}
+
+func ImageContent(data []byte, mimeType string) Content {
+	return Content{Type: contentTypeImage, Data: data, MimeType: mimeType}
+}
+
+func AudioContent(data []byte, mimeType string) Content {
+	return Content{Type: contentTypeAudio, Data: data, MimeType: mimeType}
+}
+
+// ResourceContent embeds resource contents, e.g. from Context.Resource.
+func ResourceContent(contents ResourceContents) Content {
+	return Content{Type: contentTypeResource, Resource: &contents}
+}
+
+// ResourceLinkContent points the client at a resource it can read later
+// instead of embedding it. Name, title, description and MIME type are taken
+// from r, its handler is not used.
+func ResourceLinkContent(uri string, r Resource) Content {
+	return Content{
+		Type:        contentTypeResourceLink,
+		URI:         uri,
+		Name:        r.Name,
+		Title:       r.Title,
+		Description: r.Description,
+		MimeType:    r.MimeType,
+	}
+}
+
+// AddContent appends content blocks to the tool result. They follow the text
+// summary of the data sent with Send, or make up the whole result when the
+// handler sends no data.
+func (c *Context) AddContent(blocks ...Content) {
+	c.content = append(c.content, blocks...)
+}
+
+func (c *Context) Image(data []byte, mimeType string) {
+	c.AddContent(ImageContent(data, mimeType))
+}
+
+func (c *Context) Audio(data []byte, mimeType string) {
+	c.AddContent(AudioContent(data, mimeType))
+}
+
+func (c *Context) EmbedResource(contents ResourceContents) {
+	c.AddContent(ResourceContent(contents))
+}
+
+func (c *Context) LinkResource(uri string, r Resource) {
+	c.AddContent(ResourceLinkContent(uri, r))
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestToolOutputSchema(t *testing.T) {
+	server := NewServer(nil)
+	server.Tool("weather.query", Tool{
+		InputSchema:  SchemaFor[weatherRequest](),
+		OutputSchema: SchemaFor[weatherResponse](),
+		Handler: func(ctx *Context) {
+			if ctx.Get("city") == "" {
+				ctx.Send(map[string]any{"city": 1})
+				return
+			}
+
+			ctx.Send(weatherResponse{City: ctx.Get("city"), Weather: "sunny"})
+		},
+	})
+
+	body, _ := postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodToolsList})
+	require.Equal(t, "object", gjson.Get(body, "result.tools.0.outputSchema.type").String())
+	require.Equal(t, "string", gjson.Get(body, "result.tools.0.outputSchema.properties.weather.type").String())
+
+	body, _ = postRPC(t, server, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      2,
+		Method:  methodToolsCall,
+		Params:  toolCallParams{Name: "weather.query", Arguments: weatherRequest{City: "Hangzhou"}},
+	})
+	require.False(t, gjson.Get(body, "result.isError").Bool())
+	require.Equal(t, "sunny", gjson.Get(body, "result.structuredContent.weather").String())
+	require.JSONEq(t, `{"city":"Hangzhou","weather":"sunny"}`, gjson.Get(body, "result.content.0.text").String())
+
+	body, _ = postRPC(t, server, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      3,
+		Method:  methodToolsCall,
+		Params:  toolCallParams{Name: "weather.query", Arguments: weatherRequest{}},
+	})
+	require.True(t, gjson.Get(body, "result.isError").Bool())
+	require.False(t, gjson.Get(body, "result.structuredContent").Exists())
+	require.Contains(t, gjson.Get(body, "result.content.0.text").String(), `output field "city" must be a string`)
+
+	require.PanicsWithError(t, ErrInvalidOutputSchema.Error(), func() {
+		server.Tool("bad.output", Tool{OutputSchema: StringSchema(""), Handler: func(ctx *Context) {}})
+	})
+}
+
+func TestToolContentBlocks(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G'}
+	server := NewServer(nil)
+	server.Tool("chart.render", Tool{
+		Handler: func(ctx *Context) {
+			ctx.Image(png, "image/png")
+			ctx.Audio([]byte("RIFF"), "audio/wav")
+			ctx.EmbedResource(ResourceContents{URI: "stats://history", MimeType: "application/json", Text: "{}"})
+			ctx.LinkResource("file:///tmp/chart.csv", Resource{Name: "chart.csv", MimeType: "text/csv"})
+		},
+	})
+	server.Tool("chart.summary", Tool{
+		Handler: func(ctx *Context) {
+			ctx.Send("rendered")
+			ctx.AddContent(ImageContent(png, "image/png"))
+		},
+	})
+
+	body, _ := postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodToolsCall, Params: toolCallParams{Name: "chart.render"}})
+	content := gjson.Get(body, "result.content")
+	require.Equal(t, int64(4), content.Get("#").Int())
+	require.Equal(t, contentTypeImage, content.Get("0.type").String())
+	require.Equal(t, base64.StdEncoding.EncodeToString(png), content.Get("0.data").String())
+	require.Equal(t, "image/png", content.Get("0.mimeType").String())
+	require.Equal(t, contentTypeAudio, content.Get("1.type").String())
+	require.Equal(t, contentTypeResource, content.Get("2.type").String())
+	require.Equal(t, "stats://history", content.Get("2.resource.uri").String())
+	require.Equal(t, "{}", content.Get("2.resource.text").String())
+	require.Equal(t, contentTypeResourceLink, content.Get("3.type").String())
+	require.Equal(t, "file:///tmp/chart.csv", content.Get("3.uri").String())
+	require.Equal(t, "chart.csv", content.Get("3.name").String())
+	require.False(t, gjson.Get(body, "result.structuredContent").Exists())
+
+	body, _ = postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 2, Method: methodToolsCall, Params: toolCallParams{Name: "chart.summary"}})
+	require.Equal(t, "rendered", gjson.Get(body, "result.content.0.text").String())
+	require.Equal(t, contentTypeImage, gjson.Get(body, "result.content.1.type").String())
+	require.Equal(t, "rendered", gjson.Get(body, "result.structuredContent").String())
+}
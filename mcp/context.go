@@ -4,10 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
+	"net/netip"
+	"strings"
 
 	"github.com/spf13/cast"
 	"github.com/tidwall/gjson"
+	"github.com/wonli/aqi/validate"
 )
 
 type Response struct {
@@ -24,7 +28,7 @@ type response struct {
 	set  bool
 }
 
-// Context is the per-call context passed to MCP tool handlers.
+// Context is the per-call context passed to MCP tool and resource handlers.
 //
 // It intentionally mirrors the most common ws.Context conveniences: handlers can
 // bind JSON arguments, read single fields with Get helpers, or call Send-style
@@ -33,10 +37,15 @@ type Context struct {
 	context.Context
 
 	ToolName  string
+	URI       string // requested resource URI, empty for tool calls
 	Request   *http.Request
 	Arguments json.RawMessage
 
-	response response
+	server        *Server
+	exchange      *exchange
+	progressToken json.RawMessage
+	response      response
+	content       []Content
 }
 
 func newContext(ctx context.Context, r *http.Request, toolName string, args json.RawMessage) *Context {
@@ -56,6 +65,16 @@ func (c *Context) BindingJson(v any) error {
 	return c.Bind(v)
 }
 
+// BindingValidateJson binds the arguments and runs the struct's `validate`
+// tags, like ws.Context.BindingValidateJson.
+func (c *Context) BindingValidateJson(v any) error {
+	if err := c.Bind(v); err != nil {
+		return err
+	}
+
+	return validate.Normal(validateLanguage).Validate(v)
+}
+
 func (c *Context) GetJson(v any) error {
 	return c.Bind(v)
 }
@@ -138,6 +157,41 @@ func (c *Context) ErrorString(msg string) {
 	c.Error(errors.New(msg))
 }
 
+// Resource reads a resource registered on the same server, e.g. to embed it
+// into a prompt with ResourceMessage.
+func (c *Context) Resource(uri string) ([]ResourceContents, error) {
+	if c.server == nil {
+		return nil, ErrResourceNotFound
+	}
+
+	contents, err := c.server.readResource(c.Context, c.exchange, uri)
+	if err != nil {
+		if err.Code == rpcErrorResourceNotFound {
+			return nil, ErrResourceNotFound
+		}
+
+		return nil, errors.New(err.Message)
+	}
+
+	return contents, nil
+}
+
+// Notify sends a notification related to the current request. Over HTTP the
+// response switches to an SSE stream, it returns ErrStreamUnavailable when the
+// client did not accept text/event-stream.
+func (c *Context) Notify(method string, params any) error {
+	if c.exchange == nil || c.exchange.send == nil {
+		return ErrStreamUnavailable
+	}
+
+	data, err := encodeNotification(method, params)
+	if err != nil {
+		return err
+	}
+
+	return c.exchange.send(data)
+}
+
 func (c *Context) Header(key string) string {
 	if c.Request == nil {
 		return ""
@@ -146,16 +200,73 @@ func (c *Context) Header(key string) string {
 	return c.Request.Header.Get(key)
 }
 
-func (c *Context) Caller() string {
-	if c.Request == nil {
-		return ""
+// Caller returns who made the call. With WithOAuth it is the subject and
+// scopes of the access token; otherwise only the client address is known,
+// and nothing for stdio calls.
+func (c *Context) Caller() Principal {
+	return requestPrincipal(c.Request)
+}
+
+func requestPrincipal(r *http.Request) Principal {
+	if r == nil {
+		return Principal{}
 	}
 
-	if forwarded := c.Request.Header.Get("X-Forwarded-For"); forwarded != "" {
-		return forwarded
+	if principal, ok := r.Context().Value(principalKey{}).(Principal); ok {
+		return principal
 	}
 
-	return c.Request.RemoteAddr
+	return Principal{Address: r.RemoteAddr}
+}
+
+// requestAddress is the remote address of r. Behind a trusted proxy it is the
+// last X-Forwarded-For entry that is not a trusted proxy itself, since
+// clients can prepend anything to the header.
+func (s *Server) requestAddress(r *http.Request) string {
+	if !s.trustedProxy(r.RemoteAddr) {
+		return r.RemoteAddr
+	}
+
+	var forwarded []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for _, addr := range strings.Split(value, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				forwarded = append(forwarded, addr)
+			}
+		}
+	}
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		if i == 0 || !s.trustedProxy(forwarded[i]) {
+			return forwarded[i]
+		}
+	}
+
+	return r.RemoteAddr
+}
+
+func (s *Server) trustedProxy(address string) bool {
+	if len(s.trustedProxies) == 0 {
+		return false
+	}
+
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (c *Context) responseData() (any, string, bool) {
@@ -199,6 +310,11 @@ func textSummary(data any, fallback string) string {
 	case []byte:
 		return string(v)
 	default:
-		return cast.ToString(data)
+		if text, err := cast.ToStringE(data); err == nil {
+			return text
+		}
+
+		text, _ := json.Marshal(data)
+		return string(text)
 	}
 }
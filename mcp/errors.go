@@ -3,9 +3,27 @@ package mcp
 import "errors"
 
 var (
-	ErrInvalidInputSchema = errors.New("mcp input schema must be an object schema")
-	ErrInvalidArguments   = errors.New("mcp tool arguments must be a JSON object")
-	ErrToolNotFound       = errors.New("mcp tool not found")
-	ErrToolExists         = errors.New("mcp tool already exists")
-	ErrMissingHandler     = errors.New("mcp tool handler is required")
+	ErrInvalidInputSchema  = errors.New("mcp input schema must be an object schema")
+	ErrInvalidSchema       = errors.New("mcp schema is invalid")
+	ErrInvalidOutputSchema = errors.New("mcp output schema must be an object schema")
+	ErrInvalidOutput       = errors.New("mcp tool output does not match its output schema")
+	ErrInvalidArguments    = errors.New("mcp tool arguments must be a JSON object")
+	ErrToolNotFound        = errors.New("mcp tool not found")
+	ErrToolExists          = errors.New("mcp tool already exists")
+	ErrMissingHandler      = errors.New("mcp tool handler is required")
+	ErrResourceNotFound    = errors.New("mcp resource not found")
+	ErrResourceExists      = errors.New("mcp resource already exists")
+	ErrInvalidURITemplate  = errors.New("mcp resource template must contain {variables}")
+	ErrPromptNotFound      = errors.New("mcp prompt not found")
+	ErrPromptExists        = errors.New("mcp prompt already exists")
+	ErrEmptyPrompt         = errors.New("mcp prompt handler sent no messages")
+	ErrStreamUnavailable   = errors.New("mcp client did not open a stream for notifications")
+
+	ErrConfirmationDenied   = errors.New("mcp tool call was denied")
+	ErrConfirmationDeclined = errors.New("mcp tool call was declined by the user")
+	ErrInvalidConfirmation  = errors.New("mcp confirmation token is invalid, expired or issued for another call")
+
+	ErrInvalidOAuth      = errors.New("mcp oauth config is invalid")
+	ErrInvalidToken      = errors.New("mcp access token is invalid")
+	ErrInsufficientScope = errors.New("mcp access token lacks the scopes the tool requires")
 )
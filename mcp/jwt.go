@@ -0,0 +1,441 @@
+package mcp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwtKey is a verification key from the JWKS file or OAuth.Key.
+type jwtKey struct {
+	id    string
+	alg   string
+	key   any
+	local bool // OAuth.Key, kept when the JWKS file is read again
+}
+
+type jwtHeader struct {
+	Alg  string   `json:"alg"`
+	Kid  string   `json:"kid"`
+	Crit []string `json:"crit"`
+}
+
+// tokenVerifier validates JWT access tokens. Keys from a JWKS file are read
+// again when a token names an unknown kid and the file changed, so keys can
+// be rotated without a restart.
+type tokenVerifier struct {
+	issuer   string
+	audience string
+	leeway   time.Duration
+
+	mu      sync.Mutex
+	file    string
+	modTime time.Time
+	keys    []jwtKey
+}
+
+func newTokenVerifier(config OAuth) (*tokenVerifier, error) {
+	v := &tokenVerifier{
+		issuer:   config.Issuer,
+		audience: config.Audience,
+		leeway:   config.Leeway,
+		file:     config.JWKSFile,
+	}
+	if v.audience == "" {
+		v.audience = config.Resource
+	}
+
+	if config.Key != nil {
+		key, err := localKey(config.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		v.keys = append(v.keys, key)
+	}
+
+	if v.file != "" {
+		if err := v.reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("%w: no verification key", ErrInvalidOAuth)
+	}
+
+	return v, nil
+}
+
+// verify checks the signature, issuer, audience and lifetime of token and
+// returns its claims.
+func (v *tokenVerifier) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	if len(header.Crit) > 0 {
+		return nil, fmt.Errorf("%w: unsupported critical header %s", ErrInvalidToken, strings.Join(header.Crit, ","))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range v.candidates(header) {
+		if verifySignature(header.Alg, key.key, signed, signature) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("%w: signature verification failed", ErrInvalidToken)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	return claims, v.checkClaims(claims)
+}
+
+func (v *tokenVerifier) checkClaims(claims map[string]any) error {
+	if iss, _ := claims["iss"].(string); iss != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+	}
+	if !slices.Contains(claimStrings(claims["aud"]), v.audience) {
+		return fmt.Errorf("%w: token is not issued for %s", ErrInvalidToken, v.audience)
+	}
+
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.leeway)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+// candidates returns the keys that may have signed a token with header.
+func (v *tokenVerifier) candidates(header jwtHeader) []jwtKey {
+	if header.Alg == "" || header.Alg == "none" {
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := v.match(header)
+	if len(keys) == 0 && header.Kid != "" && v.file != "" {
+		if info, err := os.Stat(v.file); err == nil && !info.ModTime().Equal(v.modTime) {
+			if err := v.reloadLocked(); err != nil {
+				logInfof("mcp jwks file=%s error=%s", v.file, err.Error())
+			}
+
+			keys = v.match(header)
+		}
+	}
+
+	return keys
+}
+
+func (v *tokenVerifier) match(header jwtHeader) []jwtKey {
+	var keys []jwtKey
+	for _, key := range v.keys {
+		if header.Kid != "" && key.id != "" && key.id != header.Kid {
+			continue
+		}
+		if key.alg != "" && key.alg != header.Alg {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func (v *tokenVerifier) reload() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.reloadLocked()
+}
+
+func (v *tokenVerifier) reloadLocked() error {
+	info, err := os.Stat(v.file)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(v.file)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidOAuth, v.file, err)
+	}
+
+	local := slices.DeleteFunc(v.keys, func(key jwtKey) bool { return !key.local })
+	v.keys = append(local, keys...)
+	v.modTime = info.ModTime()
+	return nil
+}
+
+func localKey(key any) (jwtKey, error) {
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey, []byte:
+		return jwtKey{key: key, local: true}, nil
+	default:
+		return jwtKey{}, fmt.Errorf("%w: unsupported key type %T", ErrInvalidOAuth, key)
+	}
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// parseJWKS reads the signature keys of a JWK set, keys for encryption are
+// skipped.
+func parseJWKS(data []byte) ([]jwtKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make([]jwtKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+
+		keys = append(keys, jwtKey{id: k.Kid, alg: k.Alg, key: key})
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		size := (curve.Params().BitSize + 7) / 8
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC coordinates")
+		}
+
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported OKP key %q", k.Crv)
+		}
+
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func verifySignature(alg string, key any, signed, signature []byte) error {
+	hash, err := algHash(alg)
+	if err != nil {
+		return err
+	}
+
+	digest := func() []byte {
+		h := hash.New()
+		h.Write(signed)
+		return h.Sum(nil)
+	}
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return errWrongKey
+		}
+
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errBadSignature
+		}
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errWrongKey
+		}
+
+		return rsa.VerifyPKCS1v15(pub, hash, digest(), signature)
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errWrongKey
+		}
+
+		return rsa.VerifyPSS(pub, hash, digest(), signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve.Params().BitSize != curveBits[alg] {
+			return errWrongKey
+		}
+
+		size := (curveBits[alg] + 7) / 8
+		if len(signature) != 2*size {
+			return errBadSignature
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest(), r, s) {
+			return errBadSignature
+		}
+	case "Ed":
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errWrongKey
+		}
+		if !ed25519.Verify(pub, signed, signature) {
+			return errBadSignature
+		}
+	}
+
+	return nil
+}
+
+// curveBits is the curve size each ECDSA algorithm is defined for.
+var curveBits = map[string]int{"ES256": 256, "ES384": 384, "ES512": 521}
+
+var (
+	errWrongKey     = errors.New("key does not match the token algorithm")
+	errBadSignature = errors.New("bad signature")
+)
+
+// algHash returns the hash of a JWS algorithm; EdDSA hashes internally and
+// gets crypto.SHA512 only as a placeholder.
+func algHash(alg string) (crypto.Hash, error) {
+	switch alg {
+	case "HS256", "RS256", "PS256", "ES256":
+		return crypto.SHA256, nil
+	case "HS384", "RS384", "PS384", "ES384":
+		return crypto.SHA384, nil
+	case "HS512", "RS512", "PS512", "ES512", "EdDSA", "Ed25519":
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid integer")
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
+
+// claimStrings reads a claim that may be a string or an array of strings,
+// such as aud or scp.
+func claimStrings(claim any) []string {
+	switch c := claim.(type) {
+	case string:
+		return []string{c}
+	case []any:
+		values := make([]string, 0, len(c))
+		for _, v := range c {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
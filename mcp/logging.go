@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/wonli/aqi/logger"
+)
+
+// LogLevel is an MCP log level, ordered like syslog severities.
+type LogLevel string
+
+const (
+	LogDebug     LogLevel = "debug"
+	LogInfo      LogLevel = "info"
+	LogNotice    LogLevel = "notice"
+	LogWarning   LogLevel = "warning"
+	LogError     LogLevel = "error"
+	LogCritical  LogLevel = "critical"
+	LogAlert     LogLevel = "alert"
+	LogEmergency LogLevel = "emergency"
+)
+
+// DefaultLogLevel is the minimum level sent to clients that never called
+// logging/setLevel.
+const DefaultLogLevel = LogInfo
+
+var logSeverity = map[LogLevel]int{
+	LogDebug:     0,
+	LogInfo:      1,
+	LogNotice:    2,
+	LogWarning:   3,
+	LogError:     4,
+	LogCritical:  5,
+	LogAlert:     6,
+	LogEmergency: 7,
+}
+
+func (l LogLevel) valid() bool {
+	_, ok := logSeverity[l]
+	return ok
+}
+
+func (l LogLevel) enabled(min LogLevel) bool {
+	return logSeverity[l] >= logSeverity[min]
+}
+
+// Log writes data to the application log and sends it to the client as a
+// notifications/message when level is at least the client's logging level.
+//
+// The message goes on the current request's stream, or on the session's GET
+// stream when the client did not accept one for this request.
+func (c *Context) Log(level LogLevel, data any) {
+	if !level.valid() {
+		level = LogInfo
+	}
+
+	name := c.ToolName
+	if name == "" {
+		name = c.URI
+	}
+
+	writeLog(level, name, data)
+
+	x := c.exchange
+	if x == nil || !level.enabled(x.logLevel()) {
+		return
+	}
+
+	params := logMessageParams{Level: level, Logger: name, Data: data}
+	err := c.Notify(methodNotificationsMessage, params)
+	if err != nil && x.session != nil {
+		c.server.notifySession(x.session.id, methodNotificationsMessage, params)
+	}
+}
+
+func (c *Context) Logf(level LogLevel, format string, args ...any) {
+	c.Log(level, fmt.Sprintf(format, args...))
+}
+
+func writeLog(level LogLevel, name string, data any) {
+	if logger.SugarLog == nil {
+		return
+	}
+
+	switch level {
+	case LogDebug:
+		logger.SugarLog.Debugw("mcp log", "logger", name, "data", data)
+	case LogInfo, LogNotice:
+		logger.SugarLog.Infow("mcp log", "logger", name, "data", data)
+	case LogWarning:
+		logger.SugarLog.Warnw("mcp log", "logger", name, "data", data)
+	default:
+		logger.SugarLog.Errorw("mcp log", "logger", name, "data", data, "level", level)
+	}
+}
+
+func (x *exchange) logLevel() LogLevel {
+	if x.session == nil {
+		return DefaultLogLevel
+	}
+
+	x.session.mu.Lock()
+	defer x.session.mu.Unlock()
+
+	if x.session.logLevel == "" {
+		return DefaultLogLevel
+	}
+
+	return x.session.logLevel
+}
+
+// loggingSetLevel handles logging/setLevel for the current session.
+func (s *Server) loggingSetLevel(x *exchange, params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		Level LogLevel `json:"level"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: "invalid params", Data: err.Error()}
+	}
+	if !p.Level.valid() {
+		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: "invalid log level", Data: string(p.Level)}
+	}
+
+	if x.session != nil {
+		x.session.mu.Lock()
+		x.session.logLevel = p.Level
+		x.session.mu.Unlock()
+	}
+
+	return emptyResult{}, nil
+}
+
+type loggingCapability struct{}
+
+type logMessageParams struct {
+	Level  LogLevel `json:"level"`
+	Logger string   `json:"logger,omitempty"`
+	Data   any      `json:"data"`
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Progress reports the progress of a long-running tool as
+// notifications/progress. progress must increase with every call, total is
+// omitted when it is 0.
+//
+// It does nothing when the client sent no progressToken with the call.
+func (c *Context) Progress(progress, total float64, message string) error {
+	if len(c.progressToken) == 0 {
+		return nil
+	}
+
+	return c.Notify(methodNotificationsProgress, progressParams{
+		ProgressToken: c.progressToken,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	})
+}
+
+// track makes a request cancellable through notifications/cancelled until
+// the returned release is called. Only requests of a session can be
+// cancelled, request ids of stateless clients are not unique.
+func (s *Server) track(x *exchange, id json.RawMessage) func() {
+	ctx, cancel := context.WithCancel(x.ctx)
+	x.ctx = ctx
+	if x.session == nil {
+		return cancel
+	}
+
+	key := requestKey(x, id)
+	s.inflightMu.Lock()
+	s.inflight[key] = cancel
+	s.inflightMu.Unlock()
+
+	return func() {
+		s.inflightMu.Lock()
+		delete(s.inflight, key)
+		s.inflightMu.Unlock()
+		cancel()
+	}
+}
+
+// cancelled handles notifications/cancelled from the client. Stateless
+// cancellations are ignored.
+func (s *Server) cancelled(x *exchange, params json.RawMessage) {
+	if x.session == nil {
+		return
+	}
+
+	var p struct {
+		RequestId json.RawMessage `json:"requestId"`
+		Reason    string          `json:"reason"`
+	}
+	if json.Unmarshal(params, &p) != nil || len(p.RequestId) == 0 {
+		return
+	}
+
+	key := requestKey(x, p.RequestId)
+	s.inflightMu.Lock()
+	cancel, ok := s.inflight[key]
+	s.inflightMu.Unlock()
+
+	if ok {
+		logInfof("mcp cancelled request=%s reason=%s", p.RequestId, p.Reason)
+		cancel()
+	}
+}
+
+// requestKey identifies an in-flight request within its session.
+func requestKey(x *exchange, id json.RawMessage) string {
+	return x.session.id + "/" + strings.TrimSpace(string(id))
+}
+
+type progressParams struct {
+	ProgressToken json.RawMessage `json:"progressToken"`
+	Progress      float64         `json:"progress"`
+	Total         float64         `json:"total,omitempty"`
+	Message       string          `json:"message,omitempty"`
+}
@@ -0,0 +1,174 @@
+package mcp
+
+import (
+	"bufio"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestProgress(t *testing.T) {
+	server := NewServer(nil)
+	server.Tool("import", Tool{
+		Handler: func(ctx *Context) {
+			_ = ctx.Progress(1, 2, "first half")
+			_ = ctx.Progress(2, 2, "")
+			ctx.Send("done")
+		},
+	})
+
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	res := sessionPost(t, ts.URL, "", contentTypeEventStream, rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodToolsCall,
+		Params: map[string]any{
+			"name":  "import",
+			"_meta": map[string]any{"progressToken": "tok-1"},
+		},
+	})
+	defer res.Body.Close()
+
+	reader := bufio.NewReader(res.Body)
+	_, data := readEvent(t, reader)
+	require.Equal(t, methodNotificationsProgress, gjson.Get(data, "method").String())
+	require.Equal(t, "tok-1", gjson.Get(data, "params.progressToken").String())
+	require.Equal(t, 1.0, gjson.Get(data, "params.progress").Float())
+	require.Equal(t, 2.0, gjson.Get(data, "params.total").Float())
+	require.Equal(t, "first half", gjson.Get(data, "params.message").String())
+
+	_, data = readEvent(t, reader)
+	require.Equal(t, 2.0, gjson.Get(data, "params.progress").Float())
+
+	_, data = readEvent(t, reader)
+	require.Equal(t, "done", gjson.Get(data, "result.structuredContent").String())
+
+	// Without a progressToken the call is answered with plain JSON.
+	body, _ := postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 2, Method: methodToolsCall, Params: toolCallParams{Name: "import"}})
+	require.Equal(t, "done", gjson.Get(body, "result.structuredContent").String())
+}
+
+func TestCancelled(t *testing.T) {
+	started := make(chan struct{})
+	server := NewServer(nil)
+	server.Tool("wait", Tool{
+		Handler: func(ctx *Context) {
+			close(started)
+			<-ctx.Done()
+		},
+	})
+
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	res := sessionPost(t, ts.URL, "", "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	id := res.Header.Get(headerSessionId)
+	_ = res.Body.Close()
+
+	done := make(chan string, 1)
+	go func() {
+		res := sessionPost(t, ts.URL, id, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: "call-1", Method: methodToolsCall, Params: toolCallParams{Name: "wait"}})
+		defer res.Body.Close()
+
+		body, _ := io.ReadAll(res.Body)
+		done <- string(body)
+	}()
+
+	<-started
+	res = sessionPost(t, ts.URL, id, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		Method:  methodNotificationsCancelled,
+		Params:  map[string]any{"requestId": "call-1", "reason": "user abort"},
+	})
+	_ = res.Body.Close()
+
+	select {
+	case body := <-done:
+		require.True(t, gjson.Get(body, "result.isError").Bool())
+		require.Contains(t, gjson.Get(body, "result.content.0.text").String(), "canceled")
+	case <-time.After(2 * time.Second):
+		t.Fatal("tool call was not cancelled")
+	}
+}
+
+func TestCancelledWithoutSession(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := NewServer(nil)
+	server.Tool("wait", Tool{
+		Handler: func(ctx *Context) {
+			close(started)
+			select {
+			case <-ctx.Done():
+				ctx.Send("cancelled")
+			case <-release:
+				ctx.Send("done")
+			}
+		},
+	})
+
+	done := make(chan string, 1)
+	go func() {
+		body, _ := postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodToolsCall, Params: toolCallParams{Name: "wait"}})
+		done <- body
+	}()
+
+	// Another stateless client may use the same request id.
+	<-started
+	postRPC(t, server, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		Method:  methodNotificationsCancelled,
+		Params:  map[string]any{"requestId": 1},
+	})
+	close(release)
+
+	select {
+	case body := <-done:
+		require.Equal(t, "done", gjson.Get(body, "result.structuredContent").String())
+	case <-time.After(2 * time.Second):
+		t.Fatal("tool call did not finish")
+	}
+}
+
+func TestLogging(t *testing.T) {
+	server := NewServer(nil)
+	server.Tool("noisy", Tool{
+		Handler: func(ctx *Context) {
+			ctx.Log(LogInfo, "skipped")
+			ctx.Logf(LogError, "disk %s", "full")
+			ctx.SendOk()
+		},
+	})
+
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	res := sessionPost(t, ts.URL, "", "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	id := res.Header.Get(headerSessionId)
+	_ = res.Body.Close()
+
+	res = sessionPost(t, ts.URL, id, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 2, Method: methodLoggingSetLevel, Params: map[string]any{"level": "warning"}})
+	_ = res.Body.Close()
+
+	res = sessionPost(t, ts.URL, id, contentTypeEventStream, rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 3, Method: methodToolsCall, Params: toolCallParams{Name: "noisy"}})
+	defer res.Body.Close()
+
+	reader := bufio.NewReader(res.Body)
+	_, data := readEvent(t, reader)
+	require.Equal(t, methodNotificationsMessage, gjson.Get(data, "method").String())
+	require.Equal(t, string(LogError), gjson.Get(data, "params.level").String())
+	require.Equal(t, "noisy", gjson.Get(data, "params.logger").String())
+	require.Equal(t, "disk full", gjson.Get(data, "params.data").String())
+
+	_, data = readEvent(t, reader)
+	require.Equal(t, int64(3), gjson.Get(data, "id").Int())
+
+	body, _ := postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 4, Method: methodLoggingSetLevel, Params: map[string]any{"level": "loud"}})
+	require.Equal(t, int64(rpcErrorInvalidParams), gjson.Get(body, "error.code").Int())
+}
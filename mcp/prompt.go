@@ -0,0 +1,277 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Prompt describes a reusable prompt template.
+//
+// Arguments is an object schema: each property becomes a prompt argument and
+// Required marks the mandatory ones. Clients send argument values as strings,
+// they are converted to the declared property type before validation so the
+// handler can Bind them into a typed struct.
+//
+// The handler renders the prompt with ctx.Send. It accepts a string (sent as
+// a single user message), a PromptMessage, a []PromptMessage or a PromptResult.
+type Prompt struct {
+	Title       string
+	Description string
+	Arguments   Schema
+	Handler     HandlerFunc
+}
+
+// PromptResult is the full prompts/get result.
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptMessage is a single message of a rendered prompt.
+type PromptMessage struct {
+	Role    string        `json:"role"`
+	Content PromptContent `json:"content"`
+}
+
+// PromptContent is the content of a prompt message.
+type PromptContent = Content
+
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+func UserMessage(text string) PromptMessage {
+	return PromptMessage{Role: RoleUser, Content: TextContent(text)}
+}
+
+func AssistantMessage(text string) PromptMessage {
+	return PromptMessage{Role: RoleAssistant, Content: TextContent(text)}
+}
+
+// ResourceMessage embeds resource contents into a prompt message.
+func ResourceMessage(role string, contents ResourceContents) PromptMessage {
+	return PromptMessage{Role: role, Content: ResourceContent(contents)}
+}
+
+type registeredPrompt struct {
+	name string
+	Prompt
+}
+
+func (s *Server) Prompt(name string, prompt Prompt) {
+	if err := s.registerPrompt(name, prompt); err != nil {
+		panic(err)
+	}
+
+	s.Notify(methodNotificationsPromptsListChanged, nil)
+}
+
+func (s *Server) registerPrompt(name string, prompt Prompt) error {
+	if name == "" {
+		return fmt.Errorf("mcp prompt name is required")
+	}
+	if prompt.Handler == nil {
+		return ErrMissingHandler
+	}
+
+	prompt.Arguments = normalizeSchema(prompt.Arguments)
+	if err := validateInputSchema(prompt.Arguments); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.prompts[name]; ok {
+		return ErrPromptExists
+	}
+
+	s.prompts[name] = registeredPrompt{name: name, Prompt: prompt}
+	return nil
+}
+
+func (s *Server) promptsList() promptsListResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prompts := make([]promptInfo, 0, len(s.prompts))
+	for name, prompt := range s.prompts {
+		prompts = append(prompts, promptInfo{
+			Name:        name,
+			Title:       prompt.Title,
+			Description: prompt.Description,
+			Arguments:   prompt.arguments(),
+		})
+	}
+
+	sort.Slice(prompts, func(i, j int) bool {
+		return prompts[i].Name < prompts[j].Name
+	})
+
+	return promptsListResult{Prompts: prompts}
+}
+
+func (s *Server) promptsGet(x *exchange, params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		Name      string                     `json:"name"`
+		Arguments map[string]json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: "invalid params", Data: err.Error()}
+	}
+	if p.Name == "" {
+		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: "prompt name is required"}
+	}
+
+	s.mu.RLock()
+	prompt, ok := s.prompts[p.Name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: ErrPromptNotFound.Error()}
+	}
+
+	args, err := promptArguments(prompt.Arguments, p.Arguments)
+	if err == nil {
+		args, err = validateArguments(prompt.Arguments, args)
+	}
+	if err != nil {
+		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: "invalid arguments", Data: err.Error()}
+	}
+
+	ctx := s.newContext(x.ctx, x, "", args)
+	if err := runHandler(prompt.Handler, ctx); err != nil {
+		return nil, &rpcError{Code: rpcErrorInternalError, Message: err.Error()}
+	}
+
+	data, msg, isErr := ctx.responseData()
+	if isErr {
+		return nil, &rpcError{Code: rpcErrorInternalError, Message: textSummary(data, msg)}
+	}
+
+	result, err := promptResult(data, msg)
+	if err != nil {
+		return nil, &rpcError{Code: rpcErrorInternalError, Message: err.Error()}
+	}
+	if result.Description == "" {
+		result.Description = prompt.Description
+	}
+
+	logInfof("mcp prompt=%s", p.Name)
+	return result, nil
+}
+
+func (p Prompt) arguments() []promptArgument {
+	required := make(map[string]bool, len(p.Arguments.Required))
+	for _, name := range p.Arguments.Required {
+		required[name] = true
+	}
+
+	args := make([]promptArgument, 0, len(p.Arguments.Properties))
+	for name, prop := range p.Arguments.Properties {
+		args = append(args, promptArgument{
+			Name:        name,
+			Description: prop.Description,
+			Required:    required[name],
+		})
+	}
+
+	sort.Slice(args, func(i, j int) bool {
+		return args[i].Name < args[j].Name
+	})
+
+	return args
+}
+
+// promptArguments converts string argument values to the declared property
+// types, e.g. "10" becomes 10 for an integer property.
+func promptArguments(schema Schema, values map[string]json.RawMessage) (json.RawMessage, error) {
+	args := make(map[string]json.RawMessage, len(values))
+	for name, raw := range values {
+		args[name] = raw
+
+		prop, ok := schema.Properties[name]
+		if !ok || prop.Type == "" || prop.Type == "string" {
+			continue
+		}
+
+		var text string
+		if json.Unmarshal(raw, &text) != nil {
+			continue
+		}
+
+		var err error
+		switch prop.Type {
+		case "integer":
+			_, err = strconv.ParseInt(text, 10, 64)
+		case "number":
+			_, err = strconv.ParseFloat(text, 64)
+		case "boolean":
+			var b bool
+			b, err = strconv.ParseBool(text)
+			text = strconv.FormatBool(b)
+		}
+		if err != nil || !json.Valid([]byte(text)) {
+			return nil, fmt.Errorf("argument %q must be %s", name, withArticle(prop.Type))
+		}
+
+		args[name] = json.RawMessage(text)
+	}
+
+	return json.Marshal(args)
+}
+
+func withArticle(t string) string {
+	switch t {
+	case "integer", "object", "array":
+		return "an " + t
+	default:
+		return "a " + t
+	}
+}
+
+func promptResult(data any, msg string) (PromptResult, error) {
+	switch v := data.(type) {
+	case nil:
+		if msg == "" {
+			return PromptResult{}, ErrEmptyPrompt
+		}
+
+		return PromptResult{Messages: []PromptMessage{UserMessage(msg)}}, nil
+	case string:
+		return PromptResult{Messages: []PromptMessage{UserMessage(v)}}, nil
+	case PromptMessage:
+		return PromptResult{Messages: []PromptMessage{v}}, nil
+	case []PromptMessage:
+		return PromptResult{Messages: v}, nil
+	case PromptResult:
+		return v, nil
+	case *PromptResult:
+		return *v, nil
+	default:
+		return PromptResult{}, fmt.Errorf("mcp prompt handler sent unsupported %T", data)
+	}
+}
+
+type promptsCapability struct {
+	ListChanged bool `json:"listChanged"`
+}
+
+type promptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type promptInfo struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []promptArgument `json:"arguments,omitempty"`
+}
+
+type promptsListResult struct {
+	Prompts []promptInfo `json:"prompts"`
+}
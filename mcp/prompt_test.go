@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestPromptsList(t *testing.T) {
+	server := NewServer(nil)
+	server.Prompt("logs.summary", Prompt{
+		Title:       "Summarize logs",
+		Description: "Summarize recent logs.",
+		Arguments: ObjectSchema(map[string]Schema{
+			"app":   StringSchema("App name"),
+			"lines": IntegerSchema("Number of lines"),
+		}, "app"),
+		Handler: func(ctx *Context) {},
+	})
+
+	body, status := postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, gjson.Get(body, "result.capabilities.prompts").Exists())
+
+	body, _ = postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodPromptsList})
+	require.Equal(t, "logs.summary", gjson.Get(body, "result.prompts.0.name").String())
+	require.Equal(t, "Summarize logs", gjson.Get(body, "result.prompts.0.title").String())
+	require.Equal(t, "app", gjson.Get(body, "result.prompts.0.arguments.0.name").String())
+	require.True(t, gjson.Get(body, "result.prompts.0.arguments.0.required").Bool())
+	require.Equal(t, "lines", gjson.Get(body, "result.prompts.0.arguments.1.name").String())
+	require.False(t, gjson.Get(body, "result.prompts.0.arguments.1.required").Bool())
+}
+
+func TestPromptsGet(t *testing.T) {
+	server := NewServer(nil)
+	server.ResourceTemplate("logs://{app}", Resource{
+		Handler: func(ctx *Context) {
+			ctx.Send("log lines of " + ctx.Get("app"))
+		},
+	})
+	server.Prompt("logs.summary", Prompt{
+		Description: "Summarize recent logs.",
+		Arguments: ObjectSchema(map[string]Schema{
+			"app":   StringSchema("App name"),
+			"lines": IntegerSchema("Number of lines"),
+		}, "app"),
+		Handler: func(ctx *Context) {
+			var req struct {
+				App   string `json:"app"`
+				Lines int    `json:"lines"`
+			}
+			require.NoError(t, ctx.Bind(&req))
+
+			contents, err := ctx.Resource("logs://" + req.App)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+
+			ctx.Send([]PromptMessage{
+				UserMessage("Summarize the last " + strconv.Itoa(req.Lines) + " lines."),
+				ResourceMessage(RoleUser, contents[0]),
+			})
+		},
+	})
+
+	body, _ := postRPC(t, server, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodPromptsGet,
+		Params: promptGetParams{
+			Name:      "logs.summary",
+			Arguments: map[string]string{"app": "api", "lines": "20"},
+		},
+	})
+
+	require.Equal(t, "Summarize recent logs.", gjson.Get(body, "result.description").String())
+	require.Equal(t, RoleUser, gjson.Get(body, "result.messages.0.role").String())
+	require.Equal(t, "Summarize the last 20 lines.", gjson.Get(body, "result.messages.0.content.text").String())
+	require.Equal(t, contentTypeResource, gjson.Get(body, "result.messages.1.content.type").String())
+	require.Equal(t, "logs://api", gjson.Get(body, "result.messages.1.content.resource.uri").String())
+	require.Equal(t, "log lines of api", gjson.Get(body, "result.messages.1.content.resource.text").String())
+}
+
+func TestPromptsGetInvalidArguments(t *testing.T) {
+	server := NewServer(nil)
+	called := false
+	server.Prompt("logs.summary", Prompt{
+		Arguments: ObjectSchema(map[string]Schema{
+			"app":   StringSchema("App name"),
+			"lines": IntegerSchema("Number of lines"),
+		}, "app"),
+		Handler: func(ctx *Context) {
+			called = true
+			ctx.Send("hi")
+		},
+	})
+
+	body, _ := postRPC(t, server, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodPromptsGet,
+		Params:  promptGetParams{Name: "logs.summary", Arguments: map[string]string{"lines": "20"}},
+	})
+	require.Equal(t, int64(rpcErrorInvalidParams), gjson.Get(body, "error.code").Int())
+	require.Contains(t, gjson.Get(body, "error.data").String(), "missing required argument")
+
+	body, _ = postRPC(t, server, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodPromptsGet,
+		Params:  promptGetParams{Name: "logs.summary", Arguments: map[string]string{"app": "api", "lines": "many"}},
+	})
+	require.Equal(t, int64(rpcErrorInvalidParams), gjson.Get(body, "error.code").Int())
+	require.Contains(t, gjson.Get(body, "error.data").String(), "must be an integer")
+	require.False(t, called)
+
+	body, _ = postRPC(t, server, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodPromptsGet,
+		Params:  promptGetParams{Name: "missing"},
+	})
+	require.Equal(t, ErrPromptNotFound.Error(), gjson.Get(body, "error.message").String())
+}
+
+type promptGetParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// outgoingRequest is a server-to-client request waiting for its response.
+type outgoingRequest struct {
+	session string
+	reply   chan rpcReply
+}
+
+type rpcOutgoing struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      string `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcReply struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// request sends a server-to-client request related to x, such as
+// elicitation/create, and decodes the client's result into v.
+//
+// The request goes on the stream of the current POST, or on the session's GET
+// stream when the client did not accept one. The client answers with a
+// separate POST (or a line on stdin) that handle routes back here.
+func (s *Server) request(x *exchange, method string, params any, v any) error {
+	s.requestMu.Lock()
+	s.nextRequest++
+	id := "aqi-" + strconv.FormatUint(s.nextRequest, 10)
+	reply := make(chan rpcReply, 1)
+	s.requests[id] = outgoingRequest{session: sessionId(x), reply: reply}
+	s.requestMu.Unlock()
+
+	defer func() {
+		s.requestMu.Lock()
+		delete(s.requests, id)
+		s.requestMu.Unlock()
+	}()
+
+	data, err := json.Marshal(rpcOutgoing{JSONRPC: jsonrpcVersion, ID: id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	if err := s.sendRequest(x, data); err != nil {
+		return err
+	}
+
+	select {
+	case <-x.ctx.Done():
+		return x.ctx.Err()
+	case res := <-reply:
+		if res.Error != nil {
+			return fmt.Errorf("mcp client error %d: %s", res.Error.Code, res.Error.Message)
+		}
+
+		return json.Unmarshal(res.Result, v)
+	}
+}
+
+func (s *Server) sendRequest(x *exchange, data []byte) error {
+	err := ErrStreamUnavailable
+	if x.send != nil {
+		err = x.send(data)
+	}
+
+	if err != nil && x.session != nil && x.session.attached() {
+		x.session.publish(data)
+		return nil
+	}
+
+	return err
+}
+
+// reply delivers a client response to the request waiting for it. Responses
+// from another session are ignored.
+func (s *Server) reply(x *exchange, message json.RawMessage) {
+	var res rpcReply
+	if json.Unmarshal(message, &res) != nil {
+		return
+	}
+
+	var id string
+	if json.Unmarshal(res.ID, &id) != nil {
+		return
+	}
+
+	s.requestMu.Lock()
+	req, ok := s.requests[id]
+	s.requestMu.Unlock()
+
+	if !ok || req.session != sessionId(x) {
+		return
+	}
+
+	select {
+	case req.reply <- res:
+	default:
+	}
+}
+
+// isResponse reports whether message is a JSON-RPC response rather than a
+// request or notification.
+func isResponse(message json.RawMessage) bool {
+	m := gjson.ParseBytes(message)
+	return !m.Get("method").Exists() && m.Get("id").Exists() &&
+		(m.Get("result").Exists() || m.Get("error").Exists())
+}
+
+func sessionId(x *exchange) string {
+	if x.session == nil {
+		return ""
+	}
+
+	return x.session.id
+}
@@ -0,0 +1,465 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Resource describes read-only data exposed to MCP clients.
+//
+// Handlers receive the requested URI in Context.URI. For resource templates
+// the matched URI variables are also available as arguments, so ctx.Get("id")
+// and ctx.Bind work the same way they do for tools.
+//
+// Whatever the handler sends becomes the resource contents: strings are
+// returned as text, []byte as a base64 blob, ResourceContents values are
+// returned as-is and any other value is encoded as JSON.
+type Resource struct {
+	Name        string
+	Title       string
+	Description string
+	MimeType    string
+	Handler     HandlerFunc
+}
+
+// ResourceContents is a single item returned by resources/read.
+type ResourceContents struct {
+	URI      string
+	MimeType string
+	Text     string
+	Blob     []byte
+}
+
+func (c ResourceContents) MarshalJSON() ([]byte, error) {
+	v := struct {
+		URI      string  `json:"uri"`
+		MimeType string  `json:"mimeType,omitempty"`
+		Text     *string `json:"text,omitempty"`
+		Blob     string  `json:"blob,omitempty"`
+	}{URI: c.URI, MimeType: c.MimeType}
+
+	if c.Blob != nil {
+		v.Blob = base64.StdEncoding.EncodeToString(c.Blob)
+	} else {
+		v.Text = &c.Text
+	}
+
+	return json.Marshal(v)
+}
+
+type registeredResource struct {
+	uri string
+	Resource
+}
+
+type registeredTemplate struct {
+	pattern string
+	expr    *regexp.Regexp
+	vars    []string
+	Resource
+}
+
+// uriTemplateVar matches RFC 6570 level 1 and 2 expressions: {name} matches a
+// single path segment, {+name} also matches slashes.
+var uriTemplateVar = regexp.MustCompile(`\{(\+?)([A-Za-z0-9_]+)\}`)
+
+func compileURITemplate(pattern string) (*regexp.Regexp, []string, error) {
+	matches := uriTemplateVar.FindAllStringSubmatchIndex(pattern, -1)
+	if len(matches) == 0 {
+		return nil, nil, ErrInvalidURITemplate
+	}
+
+	var expr strings.Builder
+	var vars []string
+	last := 0
+	expr.WriteString("^")
+	for _, m := range matches {
+		literal := pattern[last:m[0]]
+		if strings.ContainsAny(literal, "{}") {
+			return nil, nil, ErrInvalidURITemplate
+		}
+
+		expr.WriteString(regexp.QuoteMeta(literal))
+		if m[3] > m[2] {
+			expr.WriteString("(.+)")
+		} else {
+			expr.WriteString("([^/?#]+)")
+		}
+
+		vars = append(vars, pattern[m[4]:m[5]])
+		last = m[1]
+	}
+
+	if strings.ContainsAny(pattern[last:], "{}") {
+		return nil, nil, ErrInvalidURITemplate
+	}
+
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString("$")
+
+	return regexp.MustCompile(expr.String()), vars, nil
+}
+
+func (t registeredTemplate) match(uri string) (map[string]string, bool) {
+	values := t.expr.FindStringSubmatch(uri)
+	if values == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string, len(t.vars))
+	for i, name := range t.vars {
+		params[name] = values[i+1]
+	}
+
+	return params, true
+}
+
+// Resource registers a resource with a fixed URI, e.g. "config://app".
+func (s *Server) Resource(uri string, resource Resource) {
+	if err := s.registerResource(uri, resource); err != nil {
+		panic(err)
+	}
+
+	s.Notify(methodNotificationsResourcesListChanged, nil)
+}
+
+// ResourceTemplate registers resources addressed by a URI template, e.g.
+// "logs://recent/{lines}". Templates are matched in registration order after
+// the fixed URIs registered with Resource.
+func (s *Server) ResourceTemplate(pattern string, resource Resource) {
+	if err := s.registerTemplate(pattern, resource); err != nil {
+		panic(err)
+	}
+
+	s.Notify(methodNotificationsResourcesListChanged, nil)
+}
+
+func (s *Server) registerResource(uri string, resource Resource) error {
+	if uri == "" {
+		return fmt.Errorf("mcp resource uri is required")
+	}
+	if resource.Handler == nil {
+		return ErrMissingHandler
+	}
+	if resource.Name == "" {
+		resource.Name = uri
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.resources[uri]; ok {
+		return ErrResourceExists
+	}
+
+	s.resources[uri] = registeredResource{uri: uri, Resource: resource}
+	return nil
+}
+
+func (s *Server) registerTemplate(pattern string, resource Resource) error {
+	if resource.Handler == nil {
+		return ErrMissingHandler
+	}
+
+	expr, vars, err := compileURITemplate(pattern)
+	if err != nil {
+		return err
+	}
+	if resource.Name == "" {
+		resource.Name = pattern
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.templates {
+		if t.pattern == pattern {
+			return ErrResourceExists
+		}
+	}
+
+	s.templates = append(s.templates, registeredTemplate{pattern: pattern, expr: expr, vars: vars, Resource: resource})
+	return nil
+}
+
+// lookupResource finds the resource serving uri and the matched template variables.
+func (s *Server) lookupResource(uri string) (Resource, map[string]string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if resource, ok := s.resources[uri]; ok {
+		return resource.Resource, nil, true
+	}
+
+	for _, t := range s.templates {
+		if params, ok := t.match(uri); ok {
+			return t.Resource, params, true
+		}
+	}
+
+	return Resource{}, nil, false
+}
+
+func (s *Server) resourcesList() resourcesListResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resources := make([]resourceInfo, 0, len(s.resources))
+	for uri, resource := range s.resources {
+		resources = append(resources, resourceInfo{
+			URI:         uri,
+			Name:        resource.Name,
+			Title:       resource.Title,
+			Description: resource.Description,
+			MimeType:    resource.MimeType,
+		})
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].URI < resources[j].URI
+	})
+
+	return resourcesListResult{Resources: resources}
+}
+
+func (s *Server) resourceTemplatesList() resourceTemplatesListResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := make([]resourceTemplateInfo, 0, len(s.templates))
+	for _, t := range s.templates {
+		templates = append(templates, resourceTemplateInfo{
+			URITemplate: t.pattern,
+			Name:        t.Name,
+			Title:       t.Title,
+			Description: t.Description,
+			MimeType:    t.MimeType,
+		})
+	}
+
+	return resourceTemplatesListResult{ResourceTemplates: templates}
+}
+
+func (s *Server) resourcesRead(x *exchange, params json.RawMessage) (any, *rpcError) {
+	uri, rpcErr := resourceURI(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	contents, rpcErr := s.readResource(x.ctx, x, uri)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	logInfof("mcp resource=%s", uri)
+	return resourcesReadResult{Contents: contents}, nil
+}
+
+func (s *Server) readResource(ctx context.Context, x *exchange, uri string) ([]ResourceContents, *rpcError) {
+	resource, vars, ok := s.lookupResource(uri)
+	if !ok {
+		return nil, resourceNotFound(uri)
+	}
+
+	args := []byte(defaultEmptyArguments)
+	if len(vars) > 0 {
+		args, _ = json.Marshal(vars)
+	}
+
+	mcpCtx := s.newContext(ctx, x, "", args)
+	mcpCtx.URI = uri
+	if err := runHandler(resource.Handler, mcpCtx); err != nil {
+		return nil, &rpcError{Code: rpcErrorInternalError, Message: err.Error()}
+	}
+
+	data, msg, isErr := mcpCtx.responseData()
+	if isErr {
+		return nil, &rpcError{Code: rpcErrorInternalError, Message: textSummary(data, msg)}
+	}
+
+	contents, err := resourceContents(uri, resource.MimeType, data, msg)
+	if err != nil {
+		return nil, &rpcError{Code: rpcErrorInternalError, Message: err.Error()}
+	}
+
+	return contents, nil
+}
+
+func (s *Server) resourcesSubscribe(x *exchange, params json.RawMessage, subscribe bool) (any, *rpcError) {
+	uri, rpcErr := resourceURI(params)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	if _, _, ok := s.lookupResource(uri); !ok {
+		return nil, resourceNotFound(uri)
+	}
+
+	// Updates are pushed to the session, a stateless request has nowhere to
+	// receive them.
+	if x.session == nil {
+		return nil, &rpcError{Code: rpcErrorInvalidRequest, Message: "resource subscriptions require a session"}
+	}
+
+	id := x.session.id
+
+	s.mu.Lock()
+	if subscribe {
+		if s.subscriptions[uri] == nil {
+			s.subscriptions[uri] = map[string]struct{}{}
+		}
+
+		s.subscriptions[uri][id] = struct{}{}
+	} else {
+		delete(s.subscriptions[uri], id)
+		if len(s.subscriptions[uri]) == 0 {
+			delete(s.subscriptions, uri)
+		}
+	}
+	s.mu.Unlock()
+
+	return emptyResult{}, nil
+}
+
+// Subscribed reports whether a client has subscribed to uri.
+func (s *Server) Subscribed(uri string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.subscriptions[uri]
+	return ok
+}
+
+// ResourceUpdated notifies subscribed sessions that the resource at uri has
+// changed and should be read again. It returns false when nobody subscribed.
+func (s *Server) ResourceUpdated(uri string) bool {
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.subscriptions[uri]))
+	for id := range s.subscriptions[uri] {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+
+	for _, id := range ids {
+		s.notifySession(id, methodNotificationsResourceUpdated, resourceUpdatedParams{URI: uri})
+	}
+
+	return len(ids) > 0
+}
+
+func resourceURI(params json.RawMessage) (string, *rpcError) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return "", &rpcError{Code: rpcErrorInvalidParams, Message: "invalid params", Data: err.Error()}
+	}
+	if p.URI == "" {
+		return "", &rpcError{Code: rpcErrorInvalidParams, Message: "resource uri is required"}
+	}
+
+	return p.URI, nil
+}
+
+func resourceNotFound(uri string) *rpcError {
+	return &rpcError{
+		Code:    rpcErrorResourceNotFound,
+		Message: ErrResourceNotFound.Error(),
+		Data:    map[string]string{"uri": uri},
+	}
+}
+
+func resourceContents(uri, mimeType string, data any, msg string) ([]ResourceContents, error) {
+	withDefaults := func(c ResourceContents, fallback string) ResourceContents {
+		if c.URI == "" {
+			c.URI = uri
+		}
+		if c.MimeType == "" {
+			c.MimeType = mimeType
+		}
+		if c.MimeType == "" {
+			c.MimeType = fallback
+		}
+
+		return c
+	}
+
+	switch v := data.(type) {
+	case nil:
+		return []ResourceContents{withDefaults(ResourceContents{Text: msg}, mimeTypeText)}, nil
+	case ResourceContents:
+		return []ResourceContents{withDefaults(v, "")}, nil
+	case []ResourceContents:
+		contents := make([]ResourceContents, len(v))
+		for i, c := range v {
+			contents[i] = withDefaults(c, "")
+		}
+
+		return contents, nil
+	case string:
+		return []ResourceContents{withDefaults(ResourceContents{Text: v}, mimeTypeText)}, nil
+	case []byte:
+		return []ResourceContents{withDefaults(ResourceContents{Blob: v}, mimeTypeBinary)}, nil
+	default:
+		text, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ResourceContents{withDefaults(ResourceContents{Text: string(text)}, contentTypeJSON)}, nil
+	}
+}
+
+// runHandler calls handler and turns a panic into an error.
+func runHandler(handler HandlerFunc, ctx *Context) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("mcp handler panic: %v", recovered)
+		}
+	}()
+
+	handler(ctx)
+	return nil
+}
+
+type resourcesCapability struct {
+	Subscribe   bool `json:"subscribe"`
+	ListChanged bool `json:"listChanged"`
+}
+
+type resourceInfo struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type resourceTemplateInfo struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type resourcesListResult struct {
+	Resources []resourceInfo `json:"resources"`
+}
+
+type resourceTemplatesListResult struct {
+	ResourceTemplates []resourceTemplateInfo `json:"resourceTemplates"`
+}
+
+type resourcesReadResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+type resourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
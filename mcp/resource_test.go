@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestResourcesList(t *testing.T) {
+	server := NewServer(nil)
+	server.Resource("config://app", Resource{
+		Description: "Config snapshot.",
+		MimeType:    contentTypeJSON,
+		Handler:     func(ctx *Context) {},
+	})
+	server.ResourceTemplate("logs://recent/{lines}", Resource{
+		Name:    "recent-logs",
+		Handler: func(ctx *Context) {},
+	})
+
+	body, status := postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, gjson.Get(body, "result.capabilities.resources.subscribe").Bool())
+
+	body, _ = postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodResourcesList})
+	require.Equal(t, "config://app", gjson.Get(body, "result.resources.0.uri").String())
+	require.Equal(t, "config://app", gjson.Get(body, "result.resources.0.name").String())
+	require.Equal(t, contentTypeJSON, gjson.Get(body, "result.resources.0.mimeType").String())
+
+	body, _ = postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodResourcesTemplatesList})
+	require.Equal(t, "logs://recent/{lines}", gjson.Get(body, "result.resourceTemplates.0.uriTemplate").String())
+	require.Equal(t, "recent-logs", gjson.Get(body, "result.resourceTemplates.0.name").String())
+}
+
+func TestResourcesRead(t *testing.T) {
+	server := NewServer(nil)
+	server.Resource("config://app", Resource{
+		Handler: func(ctx *Context) {
+			ctx.Send(map[string]any{"debug": true})
+		},
+	})
+	server.Resource("files://logo", Resource{
+		MimeType: "image/png",
+		Handler: func(ctx *Context) {
+			ctx.Send([]byte{1, 2, 3})
+		},
+	})
+	server.ResourceTemplate("logs://{app}/recent/{lines}", Resource{
+		Handler: func(ctx *Context) {
+			ctx.Send(ctx.URI + " " + ctx.Get("app") + " " + ctx.Get("lines"))
+		},
+	})
+
+	body, _ := postRPC(t, server, "", readResource("config://app"))
+	require.Equal(t, "config://app", gjson.Get(body, "result.contents.0.uri").String())
+	require.Equal(t, contentTypeJSON, gjson.Get(body, "result.contents.0.mimeType").String())
+	require.JSONEq(t, `{"debug":true}`, gjson.Get(body, "result.contents.0.text").String())
+
+	body, _ = postRPC(t, server, "", readResource("files://logo"))
+	require.Equal(t, "image/png", gjson.Get(body, "result.contents.0.mimeType").String())
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte{1, 2, 3}), gjson.Get(body, "result.contents.0.blob").String())
+	require.False(t, gjson.Get(body, "result.contents.0.text").Exists())
+
+	body, _ = postRPC(t, server, "", readResource("logs://api/recent/50"))
+	require.Equal(t, "logs://api/recent/50 api 50", gjson.Get(body, "result.contents.0.text").String())
+	require.Equal(t, mimeTypeText, gjson.Get(body, "result.contents.0.mimeType").String())
+
+	body, _ = postRPC(t, server, "", readResource("logs://api/recent/50/more"))
+	require.Equal(t, int64(rpcErrorResourceNotFound), gjson.Get(body, "error.code").Int())
+	require.Equal(t, "logs://api/recent/50/more", gjson.Get(body, "error.data.uri").String())
+}
+
+func TestResourcesReadError(t *testing.T) {
+	server := NewServer(nil)
+	server.Resource("config://fail", Resource{
+		Handler: func(ctx *Context) {
+			ctx.Error(errors.New("boom"))
+		},
+	})
+	server.Resource("config://panic", Resource{
+		Handler: func(ctx *Context) {
+			panic("bad day")
+		},
+	})
+
+	body, _ := postRPC(t, server, "", readResource("config://fail"))
+	require.Equal(t, int64(rpcErrorInternalError), gjson.Get(body, "error.code").Int())
+	require.Equal(t, "boom", gjson.Get(body, "error.message").String())
+
+	body, _ = postRPC(t, server, "", readResource("config://panic"))
+	require.Equal(t, int64(rpcErrorInternalError), gjson.Get(body, "error.code").Int())
+	require.Contains(t, gjson.Get(body, "error.message").String(), "panic")
+}
+
+func TestResourcesSubscribe(t *testing.T) {
+	server := NewServer(nil)
+	server.ResourceTemplate("stats://{name}", Resource{Handler: func(ctx *Context) {}})
+
+	subscribe := rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodResourcesSubscribe,
+		Params:  resourceParams{URI: "stats://cpu"},
+	}
+
+	// Without a session there is nowhere to send updates.
+	body, _ := postRPC(t, server, "", subscribe)
+	require.Equal(t, int64(rpcErrorInvalidRequest), gjson.Get(body, "error.code").Int())
+	require.False(t, server.Subscribed("stats://cpu"))
+
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	res := sessionPost(t, ts.URL, "", "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	id := res.Header.Get(headerSessionId)
+	_ = res.Body.Close()
+
+	post := func(req rpcRequestBody) string {
+		res := sessionPost(t, ts.URL, id, "", req)
+		defer res.Body.Close()
+
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	body = post(subscribe)
+	require.True(t, gjson.Get(body, "result").Exists())
+	require.True(t, server.Subscribed("stats://cpu"))
+	require.True(t, server.ResourceUpdated("stats://cpu"))
+	require.False(t, server.ResourceUpdated("stats://memory"))
+
+	body = post(rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodResourcesSubscribe,
+		Params:  resourceParams{URI: "missing://cpu"},
+	})
+	require.Equal(t, int64(rpcErrorResourceNotFound), gjson.Get(body, "error.code").Int())
+
+	post(rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodResourcesUnsubscribe,
+		Params:  resourceParams{URI: "stats://cpu"},
+	})
+	require.False(t, server.Subscribed("stats://cpu"))
+}
+
+func TestResourceRegistration(t *testing.T) {
+	server := NewServer(nil)
+	server.Resource("config://app", Resource{Handler: func(ctx *Context) {}})
+
+	require.ErrorIs(t, server.registerResource("config://app", Resource{Handler: func(ctx *Context) {}}), ErrResourceExists)
+	require.ErrorIs(t, server.registerResource("config://other", Resource{}), ErrMissingHandler)
+	require.ErrorIs(t, server.registerTemplate("config://plain", Resource{Handler: func(ctx *Context) {}}), ErrInvalidURITemplate)
+	require.ErrorIs(t, server.registerTemplate("config://{a}/{b", Resource{Handler: func(ctx *Context) {}}), ErrInvalidURITemplate)
+
+	require.NoError(t, server.registerTemplate("files://{+path}", Resource{Handler: func(ctx *Context) {}}))
+	_, params, ok := server.lookupResource("files://a/b/c.txt")
+	require.True(t, ok)
+	require.Equal(t, "a/b/c.txt", params["path"])
+}
+
+func readResource(uri string) rpcRequestBody {
+	return rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodResourcesRead,
+		Params:  resourceParams{URI: uri},
+	}
+}
+
+type resourceParams struct {
+	URI string `json:"uri"`
+}
@@ -0,0 +1,191 @@
+package mcp
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/wonli/aqi/internal/docgen"
+	"github.com/wonli/aqi/ws"
+)
+
+// RouterFilter reports whether FromRouter exposes a ws action as a tool.
+type RouterFilter func(action string) bool
+
+// RouterOption configures FromRouter.
+type RouterOption func(*routerBridge)
+
+type routerBridge struct {
+	source string
+	policy func(action string) ToolPolicy
+}
+
+// WithRouterSource parses the ws router source in dir with the docgen parser,
+// so bridged tools get the same descriptions and parameters as the API docs.
+// Without it the tools accept any JSON object.
+func WithRouterSource(dir string) RouterOption {
+	return func(b *routerBridge) {
+		b.source = dir
+	}
+}
+
+// WithRouterPolicy sets the policy of each bridged tool, e.g. ReadOnly for
+// query actions or Destructive for deletes.
+func WithRouterPolicy(policy func(action string) ToolPolicy) RouterOption {
+	return func(b *routerBridge) {
+		b.policy = policy
+	}
+}
+
+// FromRouter wraps the ws actions registered on server and accepted by filter
+// as MCP tools, keyed by action name; a nil filter accepts every action
+// except the built-in sys.* ones:
+//
+//	server.Tools(mcp.FromRouter(ws.Default(), func(action string) bool {
+//		return strings.HasPrefix(action, "order.")
+//	}, mcp.WithRouterSource("router")))
+//
+// A call runs the action through its real HandlersChain with a temporary
+// client, like ws.ApiHandler: the MCP HTTP request supplies headers and the
+// client address. Whatever the action sends with Send becomes the tool
+// result, a non-zero SendCode becomes a tool error.
+func FromRouter(server *ws.Server, filter RouterFilter, opts ...RouterOption) map[string]Tool {
+	b := &routerBridge{}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	docs := map[string]docgen.ActionDoc{}
+	if b.source != "" {
+		actions, err := docgen.ParseRouterDir(b.source)
+		if err != nil {
+			logInfof("mcp router source=%s error=%s", b.source, err.Error())
+		}
+
+		for _, action := range actions {
+			docs[action.Name] = action
+		}
+	}
+
+	tools := map[string]Tool{}
+	for _, action := range server.Manager().Names() {
+		if action == "ping" || strings.HasPrefix(action, "sys.") || (filter != nil && !filter(action)) {
+			continue
+		}
+
+		tool := Tool{
+			InputSchema: Schema{Type: "object"},
+			Handler:     routerHandler(server, action),
+		}
+
+		if doc, ok := docs[action]; ok {
+			tool.Description = doc.Description
+			tool.InputSchema = paramsSchema(doc.Params)
+		}
+		if b.policy != nil {
+			tool.Policy = b.policy(action)
+		}
+
+		tools[action] = tool
+	}
+
+	return tools
+}
+
+// Tools registers several tools at once and notifies clients once.
+func (s *Server) Tools(tools map[string]Tool) {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := s.registerTool(name, tools[name]); err != nil {
+			panic(err)
+		}
+	}
+
+	s.Notify(methodNotificationsToolsListChanged, nil)
+}
+
+func routerHandler(server *ws.Server, action string) HandlerFunc {
+	return func(ctx *Context) {
+		var r *http.Request
+		if ctx.Request != nil {
+			r = ctx.Request.WithContext(ctx)
+		} else {
+			r, _ = http.NewRequestWithContext(ctx, http.MethodPost, "/mcp", nil)
+		}
+
+		res := server.Call(r, action, string(ctx.argumentsOrEmpty()))
+		ctx.response = response{code: res.Code, msg: res.Msg, data: res.Data, set: true}
+	}
+}
+
+// paramsSchema builds an input schema from docgen parameters. Dotted names
+// such as "page.size" become nested objects.
+func paramsSchema(params []docgen.ParamField) Schema {
+	s := Schema{Type: "object", Properties: map[string]Schema{}}
+	for _, p := range params {
+		addParam(&s, strings.Split(p.Name, "."), p)
+	}
+
+	return s
+}
+
+func addParam(s *Schema, path []string, p docgen.ParamField) {
+	name := path[0]
+	if len(path) == 1 {
+		prop := goTypeSchema(p.Type)
+		prop.Description = p.Description
+		s.Properties[name] = prop
+		if p.Required {
+			s.Required = append(s.Required, name)
+		}
+
+		return
+	}
+
+	child, ok := s.Properties[name]
+	if !ok || child.Type != "object" {
+		child = Schema{Type: "object"}
+	}
+	if child.Properties == nil {
+		child.Properties = map[string]Schema{}
+	}
+
+	addParam(&child, path[1:], p)
+	s.Properties[name] = child
+}
+
+// goTypeSchema maps a Go type as written in source, e.g. "[]*int64", to a
+// schema. Types it cannot resolve accept any value.
+func goTypeSchema(t string) Schema {
+	t = strings.TrimPrefix(t, "*")
+
+	switch {
+	case t == "[]byte":
+		return Schema{Type: "string"}
+	case strings.HasPrefix(t, "[]"):
+		items := goTypeSchema(t[2:])
+		return Schema{Type: "array", Items: &items}
+	case strings.HasPrefix(t, "map["):
+		return Schema{Type: "object"}
+	}
+
+	switch t {
+	case "string":
+		return Schema{Type: "string"}
+	case "bool":
+		return Schema{Type: "boolean"}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return Schema{Type: "integer"}
+	case "float32", "float64":
+		return Schema{Type: "number"}
+	case "time.Time":
+		return Schema{Type: "string", Format: "date-time"}
+	default:
+		return Schema{}
+	}
+}
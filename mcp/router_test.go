@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/wonli/aqi/ws"
+)
+
+const routerSource = `package router
+
+import "github.com/wonli/aqi/ws"
+
+func Actions(r ws.IRouter) {
+	r.Add("order.create", orderCreate)
+}
+
+// orderCreate 创建订单
+func orderCreate(a *ws.Context) {
+	var req struct {
+		Sku   string   ` + "`json:\"sku\"`" + ` // 商品编码
+		Count int      ` + "`json:\"count\"`" + `
+		Tags  []string ` + "`json:\"tags,omitempty\"`" + `
+	}
+	_ = a.BindingJson(&req)
+}
+`
+
+func TestFromRouter(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "action.go"), []byte(routerSource), 0o644))
+
+	wss := ws.NewInstance(nil)
+	defer wss.Close()
+
+	router := wss.Router().Use(func(a *ws.Context) {
+		if a.Client.HttpRequest.Header.Get("Authorization") != "Bearer secret" {
+			a.SendCode(1120, "Please log in first")
+			a.Abort()
+			return
+		}
+
+		a.Next()
+	})
+	router.Add("order.create", func(a *ws.Context) {
+		a.Send(ws.H{"sku": a.Get("sku"), "count": a.GetInt("count")})
+	})
+	router.Add("order.delete", func(a *ws.Context) {
+		a.SendOk()
+	})
+	router.Add("user.info", func(a *ws.Context) {})
+
+	tools := FromRouter(wss, func(action string) bool {
+		return strings.HasPrefix(action, "order.")
+	}, WithRouterSource(dir), WithRouterPolicy(func(action string) ToolPolicy {
+		return ToolPolicy{ReadOnly: action != "order.delete"}
+	}))
+	require.Len(t, tools, 2)
+	require.NotContains(t, FromRouter(wss, nil), "sys.setStatus")
+
+	create := tools["order.create"]
+	require.Equal(t, []string{"sku", "count"}, create.InputSchema.Required)
+	require.Equal(t, "商品编码", create.InputSchema.Properties["sku"].Description)
+	require.Equal(t, "integer", create.InputSchema.Properties["count"].Type)
+	require.Equal(t, "string", create.InputSchema.Properties["tags"].Items.Type)
+	require.True(t, create.Policy.ReadOnly)
+	require.False(t, tools["order.delete"].Policy.ReadOnly)
+
+	server := NewServer(nil)
+	server.Tools(tools)
+
+	call := func(token string, args any) string {
+		request := rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodToolsCall, Params: toolCallParams{Name: "order.create", Arguments: args}}
+		body, status := postRPC(t, server, token, request)
+		require.Equal(t, http.StatusOK, status)
+		return body
+	}
+
+	body := call("Bearer secret", map[string]any{"sku": "A1", "count": 2})
+	require.False(t, gjson.Get(body, "result.isError").Bool())
+	require.Equal(t, "A1", gjson.Get(body, "result.structuredContent.sku").String())
+	require.Equal(t, int64(2), gjson.Get(body, "result.structuredContent.count").Int())
+
+	body = call("", map[string]any{"sku": "A1", "count": 2})
+	require.True(t, gjson.Get(body, "result.isError").Bool())
+	require.Equal(t, int64(1120), gjson.Get(body, "result.structuredContent.code").Int())
+	require.Equal(t, "Please log in first", gjson.Get(body, "result.content.0.text").String())
+
+	body = call("Bearer secret", map[string]any{"sku": "A1"})
+	require.Equal(t, int64(rpcErrorInvalidParams), gjson.Get(body, "error.code").Int())
+}
+
+func TestFromRouterWithoutSource(t *testing.T) {
+	wss := ws.NewInstance(nil)
+	defer wss.Close()
+
+	wss.Router().Add("echo", func(a *ws.Context) {
+		a.Send(a.Get("text"))
+	})
+
+	server := NewServer(nil)
+	server.Tools(FromRouter(wss, nil))
+
+	// Stdio calls have no HTTP request, the bridge makes one up.
+	var out bytes.Buffer
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}` + "\n")
+	require.NoError(t, server.ServeStdio(context.Background(), in, &out))
+	require.Equal(t, "hi", gjson.Get(out.String(), "result.structuredContent").String())
+}
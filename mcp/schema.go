@@ -1,16 +1,37 @@
 package mcp
 
-// Schema describes the JSON object accepted by an MCP tool.
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema describes the JSON values accepted by an MCP tool or prompt.
 //
-// It intentionally models the small JSON Schema subset Aqi needs for tool
-// inputs instead of exposing map[string]any as public API.
+// It models the JSON Schema keywords Aqi validates instead of exposing
+// map[string]any as public API. SchemaFor derives one from a request struct.
 type Schema struct {
-	Type                 string            `json:"type"`
+	Type                 string            `json:"type,omitempty"`
 	Description          string            `json:"description,omitempty"`
 	Properties           map[string]Schema `json:"properties,omitempty"`
 	Required             []string          `json:"required,omitempty"`
 	Items                *Schema           `json:"items,omitempty"`
 	AdditionalProperties *bool             `json:"additionalProperties,omitempty"`
+
+	Enum      []any    `json:"enum,omitempty"`
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	MinItems  *int     `json:"minItems,omitempty"`
+	MaxItems  *int     `json:"maxItems,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"` // RE2 syntax
+	Format    string   `json:"format,omitempty"`  // date-time, date, time, email, uri, uuid, ipv4 or ipv6
+	Default   any      `json:"default,omitempty"` // filled in when the argument is missing
+	OneOf     []Schema `json:"oneOf,omitempty"`
 }
 
 func EmptyObjectSchema() Schema {
@@ -78,5 +99,250 @@ func validateInputSchema(schema Schema) error {
 		return ErrInvalidInputSchema
 	}
 
-	return nil
+	return checkSchema("", schema)
+}
+
+// validateOutputSchema accepts an empty schema, tools without one return
+// free-form results.
+func validateOutputSchema(schema Schema) error {
+	if schema.Type == "" {
+		return nil
+	}
+	if schema.Type != "object" {
+		return ErrInvalidOutputSchema
+	}
+
+	return checkSchema("", schema)
+}
+
+// SchemaFor derives a schema from the request struct T so the same struct can
+// drive argument validation, Context.BindingJson and the advertised schema:
+//
+//	type queryRequest struct {
+//		City string `json:"city" validate:"required,max=32" label:"City name"`
+//		Days int    `json:"days,omitempty" validate:"min=1,max=7"`
+//	}
+//
+//	server.Tool("weather.query", mcp.Tool{InputSchema: mcp.SchemaFor[queryRequest]()})
+//
+// Property names come from the json tag and descriptions from label.
+// validate tags map to JSON Schema where they have an equivalent: required,
+// min/max/len/gte/lte (length for strings and slices, range for numbers),
+// oneof, email, url, uuid, ipv4, ipv6 and datetime; rules after dive apply to
+// the items. Other rules are only enforced by Context.BindingValidateJson.
+// omitempty keeps the field out of required and leaves its rules unchanged.
+func SchemaFor[T any]() Schema {
+	return typeSchema(reflect.TypeFor[T](), map[reflect.Type]bool{})
+}
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	rawJSONType   = reflect.TypeFor[json.RawMessage]()
+	oneofArgument = regexp.MustCompile(`'[^']*'|\S+`)
+)
+
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return Schema{Type: "string", Format: "date-time"}
+	case rawJSONType:
+		return Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{Type: "string"}
+	case reflect.Bool:
+		return Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{Type: "string"} // base64, like encoding/json
+		}
+
+		items := typeSchema(t.Elem(), seen)
+		return Schema{Type: "array", Items: &items}
+	case reflect.Map:
+		return Schema{Type: "object"}
+	case reflect.Struct:
+		return structSchema(t, seen)
+	default:
+		return Schema{}
+	}
+}
+
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) Schema {
+	s := Schema{Type: "object", Properties: map[string]Schema{}}
+	if seen[t] {
+		// recursive types are cut off at the second level
+		return s
+	}
+
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner := structSchema(embedded, seen)
+				for key, prop := range inner.Properties {
+					if _, ok := s.Properties[key]; !ok {
+						s.Properties[key] = prop
+					}
+				}
+				s.Required = append(s.Required, inner.Required...)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := typeSchema(field.Type, seen)
+		if hasOption(options, "string") && prop.Type != "object" && prop.Type != "array" {
+			prop = Schema{Type: "string"}
+		}
+		prop.Description = field.Tag.Get("label")
+
+		// omitempty means the argument may be left out, never that it is required.
+		required, omitempty := applyValidateTag(&prop, field.Tag.Get("validate"))
+		if required && !omitempty {
+			s.Required = append(s.Required, name)
+		}
+
+		s.Properties[name] = prop
+	}
+
+	return s
+}
+
+// applyValidateTag maps validator rules onto s and reports whether the field
+// is required and whether it is marked omitempty.
+func applyValidateTag(s *Schema, tag string) (required, omitempty bool) {
+	target := s
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		if strings.Contains(key, "|") {
+			continue
+		}
+
+		switch key {
+		case "required":
+			required = required || target == s
+		case "omitempty":
+			omitempty = omitempty || target == s
+		case "dive":
+			if target.Items == nil {
+				return required, omitempty
+			}
+			target = target.Items
+		case "min", "gte":
+			setBound(target, value, true)
+		case "max", "lte":
+			setBound(target, value, false)
+		case "len":
+			setBound(target, value, true)
+			setBound(target, value, false)
+		case "oneof":
+			for _, v := range oneofArgument.FindAllString(value, -1) {
+				target.Enum = append(target.Enum, enumValue(target.Type, strings.Trim(v, "'")))
+			}
+		case "email":
+			target.Format = "email"
+		case "url", "uri", "http_url":
+			target.Format = "uri"
+		case "uuid", "uuid3", "uuid4", "uuid5":
+			target.Format = "uuid"
+		case "ipv4", "ipv6":
+			target.Format = key
+		case "datetime":
+			switch value {
+			case time.RFC3339:
+				target.Format = "date-time"
+			case time.DateOnly:
+				target.Format = "date"
+			case time.TimeOnly:
+				target.Format = "time"
+			}
+		}
+	}
+
+	return required, omitempty
+}
+
+func setBound(s *Schema, value string, lower bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	switch s.Type {
+	case "string", "array":
+		size := int(n)
+		switch {
+		case s.Type == "string" && lower:
+			s.MinLength = &size
+		case s.Type == "string":
+			s.MaxLength = &size
+		case lower:
+			s.MinItems = &size
+		default:
+			s.MaxItems = &size
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	}
+}
+
+func enumValue(t, v string) any {
+	switch t {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+
+	return v
+}
+
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
 }
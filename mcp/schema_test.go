@@ -0,0 +1,207 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type orderRequest struct {
+	Customer orderCustomer `json:"customer" validate:"required" label:"Customer"`
+	Items    []orderItem   `json:"items" validate:"required,min=1,dive"`
+	Channel  string        `json:"channel,omitempty" validate:"omitempty,oneof=web app 'pos terminal'"`
+	Coupon   *string       `json:"coupon,omitempty" validate:"omitempty,len=8"`
+	Tags     []string      `json:"tags,omitempty" validate:"max=3,dive,min=2"`
+	Deliver  time.Time     `json:"deliver"`
+	internal string
+}
+
+type orderCustomer struct {
+	Name  string `json:"name" validate:"required,min=2,max=32" label:"Customer name"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+type orderItem struct {
+	Sku   string  `json:"sku" validate:"required"`
+	Count uint    `json:"count" validate:"gte=1,lte=99"`
+	Price float64 `json:"price,string"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	s := SchemaFor[orderRequest]()
+
+	require.Equal(t, "object", s.Type)
+	require.ElementsMatch(t, []string{"customer", "items"}, s.Required)
+	require.NotContains(t, s.Properties, "internal")
+
+	customer := s.Properties["customer"]
+	require.Equal(t, "Customer", customer.Description)
+	require.ElementsMatch(t, []string{"name", "email"}, customer.Required)
+	require.Equal(t, "Customer name", customer.Properties["name"].Description)
+	require.Equal(t, 2, *customer.Properties["name"].MinLength)
+	require.Equal(t, 32, *customer.Properties["name"].MaxLength)
+	require.Equal(t, "email", customer.Properties["email"].Format)
+
+	items := s.Properties["items"]
+	require.Equal(t, "array", items.Type)
+	require.Equal(t, 1, *items.MinItems)
+	require.Equal(t, 1.0, *items.Items.Properties["count"].Minimum)
+	require.Equal(t, 99.0, *items.Items.Properties["count"].Maximum)
+	require.Equal(t, "string", items.Items.Properties["price"].Type)
+
+	require.Equal(t, []any{"web", "app", "pos terminal"}, s.Properties["channel"].Enum)
+	require.NotContains(t, s.Required, "channel")
+	require.Equal(t, 8, *s.Properties["coupon"].MinLength)
+	require.Equal(t, 3, *s.Properties["tags"].MaxItems)
+	require.Equal(t, 2, *s.Properties["tags"].Items.MinLength)
+	require.Equal(t, "date-time", s.Properties["deliver"].Format)
+}
+
+func TestSchemaForRecursive(t *testing.T) {
+	type node struct {
+		Name     string  `json:"name"`
+		Children []*node `json:"children"`
+	}
+
+	s := SchemaFor[node]()
+	require.Equal(t, "object", s.Properties["children"].Items.Type)
+	require.Empty(t, s.Properties["children"].Items.Properties)
+}
+
+func TestValidateArguments(t *testing.T) {
+	schema := SchemaFor[orderRequest]()
+	valid := `{"customer":{"name":"Ann","email":"ann@example.com"},"items":[{"sku":"A1","count":2,"price":"9.5"}],"deliver":"2026-01-02T15:04:05Z"}`
+
+	_, err := validateArguments(schema, json.RawMessage(valid))
+	require.NoError(t, err)
+
+	cases := map[string]string{
+		`{"items":[{"sku":"A1","count":1}]}`:                                                      `missing required argument "customer"`,
+		`{"customer":{"name":"Ann"},"items":[]}`:                                                  `missing required argument "customer.email"`,
+		`{"customer":{"name":"A","email":"ann@example.com"},"items":[]}`:                          `argument "customer.name" must be at least 2 characters`,
+		`{"customer":{"name":"Ann","email":"ann"},"items":[]}`:                                    `argument "customer.email" must be a valid email`,
+		`{"customer":{"name":"Ann","email":"ann@example.com"},"items":[]}`:                        `argument "items" must have at least 1 items`,
+		`{"customer":{"name":"Ann","email":"a@b.co"},"items":[{"sku":"A","count":0}]}`:            `argument "items[0].count" must be >= 1`,
+		`{"customer":{"name":"Ann","email":"a@b.co"},"items":[{"sku":"A","count":1.5}]}`:          `argument "items[0].count" must be an integer`,
+		`{"customer":{"name":"Ann","email":"a@b.co"},"items":[{"sku":"A"}],"channel":"fax"}`:      `argument "channel" must be one of ["web","app","pos terminal"]`,
+		`{"customer":{"name":"Ann","email":"a@b.co"},"items":[{"sku":"A"}],"deliver":"tomorrow"}`: `argument "deliver" must be a valid date-time`,
+		`{"customer":{"name":"Ann","email":"a@b.co"},"items":[{"sku":"A"}],"tags":["a"]}`:         `argument "tags[0]" must be at least 2 characters`,
+		`[1,2]`: ErrInvalidArguments.Error(),
+	}
+	for args, msg := range cases {
+		_, err := validateArguments(schema, json.RawMessage(args))
+		require.EqualError(t, err, msg, args)
+	}
+}
+
+func TestValidateArgumentsKeywords(t *testing.T) {
+	limit := 10.0
+	schema := ObjectSchema(map[string]Schema{
+		"code":  {Type: "string", Pattern: `^[A-Z]{3}$`},
+		"limit": {Type: "integer", Maximum: &limit, Default: 5},
+		"id": {OneOf: []Schema{
+			{Type: "integer"},
+			{Type: "string", Format: "uuid"},
+		}},
+	})
+	closed := false
+	schema.AdditionalProperties = &closed
+
+	args, err := validateArguments(schema, json.RawMessage(`{"code":"ABC","id":7}`))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), gjson.GetBytes(args, "limit").Int())
+
+	_, err = validateArguments(schema, json.RawMessage(`{"id":"8a3c3a52-4f3e-4e62-9d55-2d5a3b0f9c11"}`))
+	require.NoError(t, err)
+
+	_, err = validateArguments(schema, json.RawMessage(`{"code":"abc"}`))
+	require.EqualError(t, err, `argument "code" must match pattern "^[A-Z]{3}$"`)
+
+	_, err = validateArguments(schema, json.RawMessage(`{"limit":11}`))
+	require.EqualError(t, err, `argument "limit" must be <= 10`)
+
+	_, err = validateArguments(schema, json.RawMessage(`{"id":"nope"}`))
+	require.EqualError(t, err, `argument "id" must match exactly one of 2 schemas`)
+
+	_, err = validateArguments(schema, json.RawMessage(`{"extra":true}`))
+	require.EqualError(t, err, `unknown argument "extra"`)
+
+	// Integers may be written with an exponent or a zero fraction.
+	_, err = validateArguments(schema, json.RawMessage(`{"limit":1.0,"id":1e3}`))
+	require.NoError(t, err)
+
+	_, err = validateArguments(schema, json.RawMessage(`{"limit":2.5}`))
+	require.EqualError(t, err, `argument "limit" must be an integer`)
+}
+
+func TestSchemaForOmitempty(t *testing.T) {
+	type page struct {
+		Page  int    `json:"page" validate:"required,omitempty,min=1"`
+		Size  int    `json:"size" validate:"omitempty,max=100"`
+		Token string `json:"token" validate:"omitempty,len=8"`
+	}
+
+	// omitempty only makes the argument optional, the rules stay as they are.
+	schema := SchemaFor[page]()
+	require.Empty(t, schema.Required)
+	require.Empty(t, schema.Properties["page"].OneOf)
+	require.Equal(t, 1.0, *schema.Properties["page"].Minimum)
+	require.Equal(t, 100.0, *schema.Properties["size"].Maximum)
+
+	_, err := validateArguments(schema, json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	_, err = validateArguments(schema, json.RawMessage(`{"page":2,"token":"abcdefgh"}`))
+	require.NoError(t, err)
+
+	_, err = validateArguments(schema, json.RawMessage(`{"page":0}`))
+	require.EqualError(t, err, `argument "page" must be >= 1`)
+
+	_, err = validateArguments(schema, json.RawMessage(`{"token":"abc"}`))
+	require.EqualError(t, err, `argument "token" must be at least 8 characters`)
+}
+
+func TestToolInvalidSchemaPanics(t *testing.T) {
+	server := NewServer(nil)
+
+	require.Panics(t, func() {
+		server.Tool("bad.pattern", Tool{
+			InputSchema: ObjectSchema(map[string]Schema{"code": {Type: "string", Pattern: "("}}),
+			Handler:     func(ctx *Context) {},
+		})
+	})
+}
+
+func TestToolsCallWithSchemaFor(t *testing.T) {
+	server := NewServer(nil)
+	server.Tool("order.create", Tool{
+		InputSchema: SchemaFor[orderRequest](),
+		Handler: func(ctx *Context) {
+			var req orderRequest
+			if err := ctx.BindingValidateJson(&req); err != nil {
+				ctx.Error(err)
+				return
+			}
+
+			ctx.Send(req.Customer.Name)
+		},
+	})
+
+	body, _ := postRPC(t, server, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      1,
+		Method:  methodToolsCall,
+		Params: toolCallParams{Name: "order.create", Arguments: map[string]any{
+			"customer": map[string]any{"name": "Ann", "email": "ann@example.com"},
+			"items":    []any{map[string]any{"sku": "A1", "count": 1, "price": "1"}},
+			"deliver":  "2026-01-02T15:04:05Z",
+		}},
+	})
+	require.Equal(t, "Ann", gjson.Get(body, "result.structuredContent").String())
+
+	body, _ = postRPC(t, server, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 2, Method: methodToolsList})
+	require.Equal(t, "email", gjson.Get(body, "result.tools.0.inputSchema.properties.customer.properties.email.format").String())
+}
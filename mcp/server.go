@@ -1,16 +1,21 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
 	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/netip"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/tidwall/gjson"
+
 	"github.com/wonli/aqi"
 	"github.com/wonli/aqi/logger"
 )
@@ -25,11 +30,36 @@ type Server struct {
 	name            string
 	version         string
 
-	auth AuthFunc
+	auth           AuthFunc
+	oauth          *oauthServer
+	trustedProxies []netip.Prefix
+	maxBodySize    int64
+
+	stateless      bool
+	sessionTimeout time.Duration
+	sessionMu      sync.Mutex
+	sessions       map[string]*session
+
+	inflightMu sync.Mutex
+	inflight   map[string]context.CancelFunc
+
+	requestMu   sync.Mutex
+	nextRequest uint64
+	requests    map[string]outgoingRequest
+
+	approver       Approver
+	audit          AuditFunc
+	confirmTimeout time.Duration
+	confirmMu      sync.Mutex
+	confirmations  map[string]pendingConfirmation
 
-	mu          sync.RWMutex
-	initialized bool
-	tools       map[string]registeredTool
+	mu            sync.RWMutex
+	initialized   bool
+	tools         map[string]registeredTool
+	resources     map[string]registeredResource
+	templates     []registeredTemplate
+	prompts       map[string]registeredPrompt
+	subscriptions map[string]map[string]struct{}
 }
 
 func NewServer(app *aqi.AppConfig, options ...Option) *Server {
@@ -38,7 +68,17 @@ func NewServer(app *aqi.AppConfig, options ...Option) *Server {
 		protocolVersion: DefaultProtocolVersion,
 		name:            DefaultServerName,
 		version:         DefaultServerVersion,
+		sessionTimeout:  DefaultSessionTimeout,
+		maxBodySize:     DefaultMaxBodySize,
+		sessions:        map[string]*session{},
+		inflight:        map[string]context.CancelFunc{},
+		requests:        map[string]outgoingRequest{},
+		confirmTimeout:  DefaultConfirmTimeout,
+		confirmations:   map[string]pendingConfirmation{},
 		tools:           map[string]registeredTool{},
+		resources:       map[string]registeredResource{},
+		prompts:         map[string]registeredPrompt{},
+		subscriptions:   map[string]map[string]struct{}{},
 	}
 
 	for _, option := range options {
@@ -74,6 +114,54 @@ func WithVersion(version string) Option {
 	}
 }
 
+// WithStateless disables Streamable HTTP sessions: initialize returns no
+// Mcp-Session-Id and the GET stream is not offered.
+func WithStateless() Option {
+	return func(s *Server) {
+		s.stateless = true
+	}
+}
+
+// WithSessionTimeout sets how long an idle session is kept, 30 minutes by default.
+func WithSessionTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		if timeout > 0 {
+			s.sessionTimeout = timeout
+		}
+	}
+}
+
+// WithMaxBodySize limits the body of a POST request to size bytes, 4 MiB by
+// default. Larger requests are rejected with 413 Request Entity Too Large.
+func WithMaxBodySize(size int64) Option {
+	return func(s *Server) {
+		if size > 0 {
+			s.maxBodySize = size
+		}
+	}
+}
+
+// WithTrustedProxies lists the addresses or CIDR ranges of reverse proxies in
+// front of the server. The client address is taken from X-Forwarded-For only
+// when the request comes from one of them, otherwise the header is ignored.
+// It panics on an invalid address.
+func WithTrustedProxies(proxies ...string) Option {
+	return func(s *Server) {
+		for _, proxy := range proxies {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				addr, addrErr := netip.ParseAddr(proxy)
+				if addrErr != nil {
+					panic(fmt.Errorf("mcp trusted proxy %q: %w", proxy, err))
+				}
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+
+			s.trustedProxies = append(s.trustedProxies, prefix.Masked())
+		}
+	}
+}
+
 func WithBearerToken(token string) Option {
 	return func(s *Server) {
 		if token == "" {
@@ -102,6 +190,8 @@ func (s *Server) Tool(name string, tool Tool) {
 	if err := s.registerTool(name, tool); err != nil {
 		panic(err)
 	}
+
+	s.Notify(methodNotificationsToolsListChanged, nil)
 }
 
 func (s *Server) registerTool(name string, tool Tool) error {
@@ -116,6 +206,9 @@ func (s *Server) registerTool(name string, tool Tool) error {
 	if err := validateInputSchema(tool.InputSchema); err != nil {
 		return err
 	}
+	if err := validateOutputSchema(tool.OutputSchema); err != nil {
+		return err
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -128,15 +221,24 @@ func (s *Server) registerTool(name string, tool Tool) error {
 	return nil
 }
 
+// HTTPHandler serves the Streamable HTTP transport.
+//
+// POST carries JSON-RPC messages. The response is plain JSON, or an SSE stream
+// when the client accepts text/event-stream and the handler sends
+// notifications while running. initialize creates a session returned in the
+// Mcp-Session-Id header, GET opens the session's server-to-client stream and
+// DELETE ends it. Requests without a session id are served statelessly.
 func (s *Server) HTTPHandler() http.Handler {
 	return http.HandlerFunc(s.serveHTTP)
 }
 
 func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.Header().Set("Allow", http.MethodPost)
-		http.Error(w, "mcp server supports POST only", http.StatusMethodNotAllowed)
-		return
+	if s.oauth != nil {
+		if r = s.authenticate(w, r); r == nil {
+			return
+		}
+	} else {
+		r = r.WithContext(context.WithValue(r.Context(), principalKey{}, Principal{Address: s.requestAddress(r)}))
 	}
 
 	if s.auth != nil && !s.auth(r) {
@@ -144,10 +246,28 @@ func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req rpcRequest
-	decoder := json.NewDecoder(r.Body)
-	decoder.UseNumber()
-	if err := decoder.Decode(&req); err != nil {
+	switch r.Method {
+	case http.MethodPost:
+		s.servePost(w, r)
+	case http.MethodGet:
+		s.serveStream(w, r)
+	case http.MethodDelete:
+		s.serveDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) servePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
 		writeRPC(w, rpcResponse{
 			JSONRPC: jsonrpcVersion,
 			Error:   &rpcError{Code: rpcErrorParseError, Message: "parse error", Data: err.Error()},
@@ -155,63 +275,198 @@ func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.JSONRPC != jsonrpcVersion || req.Method == "" {
-		writeRPC(w, rpcResponse{
+	ss, ok := s.requestSession(w, r, false)
+	if !ok {
+		return
+	}
+
+	if ss == nil && !s.stateless && hasMethod(body, methodInitialize) {
+		ss = s.newSession(requestPrincipal(r).Subject)
+		w.Header().Set(headerSessionId, ss.id)
+	}
+
+	stream := newPostStream(w, r, ss)
+	res := s.serve(body, func() *exchange {
+		return &exchange{ctx: r.Context(), r: r, session: ss, transport: TransportHTTP, send: stream.send}
+	})
+
+	if res == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if s.oauth != nil && s.scopeChallenge(w, res) {
+		return
+	}
+
+	stream.finish(res)
+}
+
+// serve handles a JSON-RPC message or batch and returns the response to
+// write, nil when it contained only notifications.
+func (s *Server) serve(body []byte, newExchange func() *exchange) any {
+	body = bytes.TrimSpace(body)
+	if err := json.Unmarshal(body, new(json.RawMessage)); err != nil {
+		return rpcResponse{
+			JSONRPC: jsonrpcVersion,
+			Error:   &rpcError{Code: rpcErrorParseError, Message: "parse error", Data: err.Error()},
+		}
+	}
+
+	if body[0] != '[' {
+		res := s.handle(newExchange(), body)
+		if res == nil {
+			return nil
+		}
+
+		return *res
+	}
+
+	var messages []json.RawMessage
+	if err := json.Unmarshal(body, &messages); err != nil || len(messages) == 0 {
+		return rpcResponse{
+			JSONRPC: jsonrpcVersion,
+			Error:   &rpcError{Code: rpcErrorInvalidRequest, Message: "invalid request"},
+		}
+	}
+
+	responses := make([]rpcResponse, 0, len(messages))
+	for _, message := range messages {
+		if res := s.handle(newExchange(), message); res != nil {
+			responses = append(responses, *res)
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil
+	}
+
+	return responses
+}
+
+// handle dispatches a single JSON-RPC message, notifications and responses to
+// server requests return nil.
+func (s *Server) handle(x *exchange, message json.RawMessage) *rpcResponse {
+	if isResponse(message) {
+		s.reply(x, message)
+		return nil
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(message, &req); err != nil || req.JSONRPC != jsonrpcVersion || req.Method == "" {
+		return &rpcResponse{
 			JSONRPC: jsonrpcVersion,
 			ID:      req.ID,
 			Error:   &rpcError{Code: rpcErrorInvalidRequest, Message: "invalid request"},
-		})
-		return
+		}
 	}
 
-	result, rpcErr, notification, status := s.dispatch(r, req)
+	result, rpcErr, notification := s.dispatch(x, req)
 	if notification {
-		w.WriteHeader(status)
-		return
+		return nil
 	}
 
-	res := rpcResponse{JSONRPC: jsonrpcVersion, ID: req.ID}
+	res := &rpcResponse{JSONRPC: jsonrpcVersion, ID: req.ID}
 	if rpcErr != nil {
 		res.Error = rpcErr
 	} else {
 		res.Result = result
 	}
 
-	writeRPC(w, res)
+	return res
 }
 
-func (s *Server) dispatch(r *http.Request, req rpcRequest) (any, *rpcError, bool, int) {
+func (s *Server) dispatch(x *exchange, req rpcRequest) (any, *rpcError, bool) {
 	notification := len(req.ID) == 0
+	if !notification {
+		release := s.track(x, req.ID)
+		defer release()
+	}
 
 	switch req.Method {
 	case methodInitialize:
-		return s.initialize(), nil, notification, http.StatusAccepted
+		return s.initialize(x, req.Params), nil, notification
 	case methodNotificationsInitialized:
 		s.mu.Lock()
 		s.initialized = true
 		s.mu.Unlock()
-		return nil, nil, true, http.StatusAccepted
+
+		if x.session != nil {
+			x.session.mu.Lock()
+			x.session.initialized = true
+			x.session.mu.Unlock()
+		}
+
+		return nil, nil, true
+	case methodNotificationsCancelled:
+		s.cancelled(x, req.Params)
+		return nil, nil, true
+	case methodLoggingSetLevel:
+		result, err := s.loggingSetLevel(x, req.Params)
+		if err != nil {
+			return nil, err, notification
+		}
+
+		return result, nil, notification
 	case methodPing:
-		return emptyResult{}, nil, notification, http.StatusAccepted
+		return emptyResult{}, nil, notification
 	case methodToolsList:
-		return s.toolsList(), nil, notification, http.StatusAccepted
+		return s.toolsList(), nil, notification
 	case methodToolsCall:
-		result, err := s.toolsCall(r, req.Params)
+		result, err := s.toolsCall(x, req.Params)
+		if err != nil {
+			return nil, err, notification
+		}
+
+		return result, nil, notification
+	case methodResourcesList:
+		return s.resourcesList(), nil, notification
+	case methodResourcesTemplatesList:
+		return s.resourceTemplatesList(), nil, notification
+	case methodResourcesRead:
+		result, err := s.resourcesRead(x, req.Params)
+		if err != nil {
+			return nil, err, notification
+		}
+
+		return result, nil, notification
+	case methodResourcesSubscribe, methodResourcesUnsubscribe:
+		result, err := s.resourcesSubscribe(x, req.Params, req.Method == methodResourcesSubscribe)
 		if err != nil {
-			return nil, err, notification, http.StatusAccepted
+			return nil, err, notification
 		}
 
-		return result, nil, notification, http.StatusAccepted
+		return result, nil, notification
+	case methodPromptsList:
+		return s.promptsList(), nil, notification
+	case methodPromptsGet:
+		result, err := s.promptsGet(x, req.Params)
+		if err != nil {
+			return nil, err, notification
+		}
+
+		return result, nil, notification
 	default:
-		return nil, &rpcError{Code: rpcErrorMethodNotFound, Message: "method not found"}, notification, http.StatusAccepted
+		return nil, &rpcError{Code: rpcErrorMethodNotFound, Message: "method not found"}, notification
 	}
 }
 
-func (s *Server) initialize() initializeResult {
+func (s *Server) initialize(x *exchange, params json.RawMessage) initializeResult {
+	if x.session != nil && gjson.GetBytes(params, "capabilities.elicitation").Exists() {
+		x.session.mu.Lock()
+		x.session.elicitation = true
+		x.session.mu.Unlock()
+	}
+
 	return initializeResult{
 		ProtocolVersion: s.protocolVersion,
-		Capabilities:    capabilities{Tools: toolsCapability{ListChanged: false}},
-		ServerInfo:      serverInfo{Name: s.name, Version: s.version},
+		Capabilities: capabilities{
+			Tools:     toolsCapability{ListChanged: true},
+			Resources: &resourcesCapability{Subscribe: true, ListChanged: true},
+			Prompts:   &promptsCapability{ListChanged: true},
+			Logging:   &loggingCapability{},
+		},
+		ServerInfo: serverInfo{Name: s.name, Version: s.version},
 	}
 }
 
@@ -221,21 +476,31 @@ func (s *Server) toolsList() toolsListResult {
 
 	tools := make([]toolInfo, 0, len(s.tools))
 	for name, tool := range s.tools {
+		inputSchema := tool.InputSchema
+		if tool.needsConfirmation() {
+			inputSchema = confirmationSchema(inputSchema)
+		}
+
 		tools = append(tools, toolInfo{
-			Name:        name,
-			Description: tool.Description,
-			InputSchema: tool.InputSchema,
-			Annotations: tool.annotations(),
+			Name:         name,
+			Description:  tool.Description,
+			InputSchema:  inputSchema,
+			OutputSchema: tool.outputSchema(),
+			Annotations:  tool.annotations(),
 		})
 	}
 
 	return toolsListResult{Tools: tools}
 }
 
-func (s *Server) toolsCall(r *http.Request, params json.RawMessage) (any, *rpcError) {
+func (s *Server) toolsCall(x *exchange, params json.RawMessage) (any, *rpcError) {
 	var p struct {
 		Name      string          `json:"name"`
 		Arguments json.RawMessage `json:"arguments"`
+		Meta      struct {
+			ProgressToken     json.RawMessage `json:"progressToken"`
+			ConfirmationToken string          `json:"confirmationToken"`
+		} `json:"_meta"`
 	}
 	if err := json.Unmarshal(params, &p); err != nil {
 		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: "invalid params", Data: err.Error()}
@@ -254,19 +519,36 @@ func (s *Server) toolsCall(r *http.Request, params json.RawMessage) (any, *rpcEr
 		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: ErrToolNotFound.Error()}
 	}
 
-	if err := validateArguments(tool.InputSchema, p.Arguments); err != nil {
+	if err := s.authorizeTool(x, tool); err != nil {
+		return nil, err
+	}
+
+	token := p.Meta.ConfirmationToken
+	if tool.needsConfirmation() {
+		token, p.Arguments = confirmationToken(token, p.Arguments)
+	}
+
+	args, err := validateArguments(tool.InputSchema, p.Arguments)
+	if err != nil {
 		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: "invalid arguments", Data: err.Error()}
 	}
 
+	x.progressToken = p.Meta.ProgressToken
+	if tool.needsConfirmation() {
+		if result, ok := s.confirm(x, tool, args, token); !ok {
+			return result, nil
+		}
+	}
+
 	start := time.Now()
-	result := s.callTool(r, tool, p.Arguments)
+	result := s.callTool(x, tool, args)
 	logInfof("mcp tool=%s duration=%s error=%t", p.Name, time.Since(start), result.IsError)
 
 	return result, nil
 }
 
-func (s *Server) callTool(r *http.Request, tool registeredTool, args json.RawMessage) toolResult {
-	ctx := r.Context()
+func (s *Server) callTool(x *exchange, tool registeredTool, args json.RawMessage) toolResult {
+	ctx := x.ctx
 	cancel := func() {}
 	if tool.Policy.Timeout > 0 {
 		ctx, cancel = context.WithTimeout(ctx, tool.Policy.Timeout)
@@ -274,7 +556,7 @@ func (s *Server) callTool(r *http.Request, tool registeredTool, args json.RawMes
 	defer cancel()
 
 	done := make(chan callResult, 1)
-	mcpCtx := newContext(ctx, r, tool.name, args)
+	mcpCtx := s.newContext(ctx, x, tool.name, args)
 	go func() {
 		defer func() {
 			if recovered := recover(); recovered != nil {
@@ -297,100 +579,33 @@ func (s *Server) callTool(r *http.Request, tool registeredTool, args json.RawMes
 		data, msg, isErr := res.ctx.responseData()
 		if isErr {
 			return toolResult{
-				Content:           []contentBlock{{Type: contentTypeText, Text: textSummary(data, msg)}},
+				Content:           []Content{TextContent(textSummary(data, msg))},
 				StructuredContent: data,
 				IsError:           true,
 			}
 		}
 
-		return successToolResult(data, msg)
-	}
-}
-
-func validateArguments(schema Schema, arguments json.RawMessage) error {
-	var args map[string]json.RawMessage
-	if err := json.Unmarshal(arguments, &args); err != nil {
-		return ErrInvalidArguments
-	}
-	if args == nil {
-		return ErrInvalidArguments
-	}
-
-	for _, name := range schema.Required {
-		if _, ok := args[name]; !ok {
-			return fmt.Errorf("missing required argument %q", name)
-		}
-	}
-
-	for name, prop := range schema.Properties {
-		value, ok := args[name]
-		if !ok {
-			continue
+		if tool.OutputSchema.Type != "" {
+			if err := validateOutput(tool.OutputSchema, data); err != nil {
+				logInfof("mcp tool=%s invalid output: %s", tool.name, err.Error())
+				return errorToolResult(fmt.Errorf("%w: %v", ErrInvalidOutput, err))
+			}
 		}
 
-		if err := validateArgumentType(name, prop, value); err != nil {
-			return err
-		}
+		return successToolResult(data, msg, res.ctx.content)
 	}
-
-	return nil
 }
 
-func validateArgumentType(name string, schema Schema, value any) error {
-	if schema.Type == "" || value == nil {
-		return nil
-	}
-
-	raw, ok := value.(json.RawMessage)
-	if !ok {
-		return nil
-	}
-
-	switch schema.Type {
-	case "string":
-		var v string
-		if err := json.Unmarshal(raw, &v); err != nil {
-			return fmt.Errorf("argument %q must be a string", name)
-		}
-	case "number":
-		var v json.Number
-		if err := json.Unmarshal(raw, &v); err != nil {
-			return fmt.Errorf("argument %q must be a number", name)
-		}
-		if _, err := v.Float64(); err != nil {
-			return fmt.Errorf("argument %q must be a number", name)
-		}
-	case "integer":
-		var v json.Number
-		if err := json.Unmarshal(raw, &v); err != nil {
-			return fmt.Errorf("argument %q must be an integer", name)
-		}
-		if _, err := v.Int64(); err != nil {
-			return fmt.Errorf("argument %q must be an integer", name)
-		}
-	case "boolean":
-		var v bool
-		if err := json.Unmarshal(raw, &v); err != nil {
-			return fmt.Errorf("argument %q must be a boolean", name)
-		}
-	case "object":
-		var v map[string]json.RawMessage
-		if err := json.Unmarshal(raw, &v); err != nil {
-			return fmt.Errorf("argument %q must be an object", name)
-		}
-	case "array":
-		var v []json.RawMessage
-		if err := json.Unmarshal(raw, &v); err != nil {
-			return fmt.Errorf("argument %q must be an array", name)
-		}
+// successToolResult summarizes data as text for clients that ignore
+// structuredContent, followed by the content blocks the handler added. A
+// handler that only added content blocks gets no summary.
+func successToolResult(data any, msg string, content []Content) toolResult {
+	if data == nil && msg == "" && len(content) > 0 {
+		return toolResult{Content: content}
 	}
 
-	return nil
-}
-
-func successToolResult(data any, msg string) toolResult {
 	return toolResult{
-		Content:           []contentBlock{{Type: contentTypeText, Text: textSummary(data, msg)}},
+		Content:           append([]Content{TextContent(textSummary(data, msg))}, content...),
 		StructuredContent: data,
 	}
 }
@@ -401,20 +616,51 @@ func errorToolResult(err error) toolResult {
 	}
 
 	return toolResult{
-		Content: []contentBlock{{
-			Type: contentTypeText,
-			Text: err.Error(),
-		}},
+		Content: []Content{TextContent(err.Error())},
 		IsError: true,
 	}
 }
 
-func writeRPC(w http.ResponseWriter, res rpcResponse) {
+func writeRPC(w http.ResponseWriter, res any) {
 	w.Header().Set("Content-Type", contentTypeJSON)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(res)
 }
 
+func hasMethod(body []byte, method string) bool {
+	message := gjson.ParseBytes(body)
+	if !message.IsArray() {
+		return message.Get("method").String() == method
+	}
+
+	for _, m := range message.Array() {
+		if m.Get("method").String() == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exchange carries the transport state of a single JSON-RPC message.
+type exchange struct {
+	ctx       context.Context
+	r         *http.Request
+	session   *session
+	transport string
+	send      func(data []byte) error // writes a message on the request's stream
+
+	progressToken json.RawMessage
+}
+
+func (s *Server) newContext(ctx context.Context, x *exchange, toolName string, args json.RawMessage) *Context {
+	c := newContext(ctx, x.r, toolName, args)
+	c.server = s
+	c.exchange = x
+	c.progressToken = x.progressToken
+	return c
+}
+
 type callResult struct {
 	ctx *Context
 	err error
@@ -453,7 +699,10 @@ type initializeResult struct {
 type emptyResult struct{}
 
 type capabilities struct {
-	Tools toolsCapability `json:"tools"`
+	Tools     toolsCapability      `json:"tools"`
+	Resources *resourcesCapability `json:"resources,omitempty"`
+	Prompts   *promptsCapability   `json:"prompts,omitempty"`
+	Logging   *loggingCapability   `json:"logging,omitempty"`
 }
 
 type toolsCapability struct {
@@ -470,21 +719,17 @@ type toolsListResult struct {
 }
 
 type toolInfo struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description,omitempty"`
-	InputSchema Schema          `json:"inputSchema"`
-	Annotations ToolAnnotations `json:"annotations,omitempty"`
-}
-
-type contentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Name         string          `json:"name"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  Schema          `json:"inputSchema"`
+	OutputSchema *Schema         `json:"outputSchema,omitempty"`
+	Annotations  ToolAnnotations `json:"annotations,omitempty"`
 }
 
 type toolResult struct {
-	Content           []contentBlock `json:"content"`
-	StructuredContent any            `json:"structuredContent,omitempty"`
-	IsError           bool           `json:"isError,omitempty"`
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
 }
 
 func logInfof(template string, args ...any) {
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -289,6 +290,16 @@ func TestHandlerPanic(t *testing.T) {
 	require.Contains(t, gjson.Get(body, "result.content.0.text").String(), "panic")
 }
 
+func TestMaxBodySize(t *testing.T) {
+	server := NewServer(nil, WithMaxBodySize(64))
+
+	_, status := postRawRPC(t, server, "", `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	require.Equal(t, http.StatusOK, status)
+
+	_, status = postRawRPC(t, server, "", `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"pad":"`+strings.Repeat("x", 64)+`"}}`)
+	require.Equal(t, http.StatusRequestEntityTooLarge, status)
+}
+
 func postRPC(t *testing.T, server *Server, auth string, req rpcRequestBody) (string, int) {
 	t.Helper()
 
@@ -0,0 +1,525 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wonli/aqi/utils"
+)
+
+const (
+	headerSessionId   = "Mcp-Session-Id"
+	headerLastEventId = "Last-Event-ID"
+
+	contentTypeEventStream = "text/event-stream"
+
+	// sseKeepalive is the interval of keepalive comments on idle streams.
+	sseKeepalive = 15 * time.Second
+
+	// maxSessionEvents bounds the events a session keeps for resumption.
+	maxSessionEvents = 256
+
+	DefaultSessionTimeout = 30 * time.Minute
+)
+
+// sseEvent is a message written to an SSE stream. Events of one session share
+// an increasing id so a client can resume with Last-Event-ID.
+type sseEvent struct {
+	id     uint64
+	stream string
+	data   []byte
+}
+
+// session is a Streamable HTTP session created by initialize.
+type session struct {
+	id      string
+	subject string // subject of the access token that initialized the session
+
+	mu          sync.Mutex
+	lastSeen    time.Time
+	initialized bool
+	logLevel    LogLevel
+	elicitation bool // the client declared the elicitation capability
+	nextEvent   uint64
+	nextStream  uint64
+	events      []sseEvent
+	pending     []sseEvent    // GET stream events published while no stream was attached
+	stream      chan sseEvent // attached GET stream
+	closed      chan struct{}
+}
+
+func newSession() *session {
+	return &session{
+		id:       utils.GetRandomString(32),
+		lastSeen: time.Now(),
+		closed:   make(chan struct{}),
+	}
+}
+
+// record assigns an id to a message of stream and keeps it for resumption.
+func (ss *session) record(stream string, data []byte) sseEvent {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.recordLocked(stream, data)
+}
+
+func (ss *session) recordLocked(stream string, data []byte) sseEvent {
+	ss.nextEvent++
+	event := sseEvent{id: ss.nextEvent, stream: stream, data: data}
+	ss.events = append(ss.events, event)
+	if len(ss.events) > maxSessionEvents {
+		ss.events = ss.events[len(ss.events)-maxSessionEvents:]
+	}
+
+	return event
+}
+
+// publish sends a message on the GET stream, or queues it until one attaches.
+func (ss *session) publish(data []byte) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	event := ss.recordLocked("", data)
+	if ss.stream != nil {
+		select {
+		case ss.stream <- event:
+			return
+		default:
+		}
+	}
+
+	ss.pending = append(ss.pending, event)
+	if len(ss.pending) > maxSessionEvents {
+		ss.pending = ss.pending[len(ss.pending)-maxSessionEvents:]
+	}
+}
+
+// attached reports whether a GET stream is connected.
+func (ss *session) attached() bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.stream != nil
+}
+
+// attach connects a GET stream and returns the events to replay first.
+//
+// Without Last-Event-ID the queued events are replayed. With it, the events
+// after lastId of the stream lastId belongs to are replayed, which also
+// resumes an interrupted POST response stream.
+func (ss *session) attach(lastId uint64, resume bool) (chan sseEvent, []sseEvent) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.stream != nil {
+		close(ss.stream)
+	}
+
+	ss.stream = make(chan sseEvent, 64)
+
+	var replay []sseEvent
+	if !resume {
+		replay, ss.pending = ss.pending, nil
+		return ss.stream, replay
+	}
+
+	stream := ""
+	for _, event := range ss.events {
+		if event.id == lastId {
+			stream = event.stream
+			break
+		}
+	}
+
+	for _, event := range ss.events {
+		if event.id > lastId && event.stream == stream {
+			replay = append(replay, event)
+		}
+	}
+
+	// Resuming a POST stream also flushes the queued GET stream messages.
+	if stream != "" {
+		replay = append(replay, ss.pending...)
+	}
+
+	ss.pending = nil
+	return ss.stream, replay
+}
+
+func (ss *session) detach(stream chan sseEvent) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.stream == stream {
+		ss.stream = nil
+	}
+}
+
+func (ss *session) newStream() string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.nextStream++
+	return "post-" + strconv.FormatUint(ss.nextStream, 10)
+}
+
+func (ss *session) touch() {
+	ss.mu.Lock()
+	ss.lastSeen = time.Now()
+	ss.mu.Unlock()
+}
+
+func (ss *session) expired(timeout time.Duration) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return ss.stream == nil && time.Since(ss.lastSeen) > timeout
+}
+
+func (ss *session) close() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	select {
+	case <-ss.closed:
+	default:
+		close(ss.closed)
+	}
+}
+
+// newSession creates a session owned by subject and drops the expired ones.
+func (s *Server) newSession(subject string) *session {
+	ss := newSession()
+	ss.subject = subject
+
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	for id, old := range s.sessions {
+		if old.expired(s.sessionTimeout) {
+			s.removeSessionLocked(id)
+		}
+	}
+
+	s.sessions[ss.id] = ss
+	return ss
+}
+
+func (s *Server) session(id string) *session {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	ss, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	if ss.expired(s.sessionTimeout) {
+		s.removeSessionLocked(id)
+		return nil
+	}
+
+	ss.touch()
+	return ss
+}
+
+func (s *Server) removeSession(id string) bool {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	_, ok := s.sessions[id]
+	s.removeSessionLocked(id)
+	return ok
+}
+
+func (s *Server) removeSessionLocked(id string) {
+	ss, ok := s.sessions[id]
+	if !ok {
+		return
+	}
+
+	delete(s.sessions, id)
+	ss.close()
+
+	s.mu.Lock()
+	for uri, sessions := range s.subscriptions {
+		delete(sessions, id)
+		if len(sessions) == 0 {
+			delete(s.subscriptions, uri)
+		}
+	}
+	s.mu.Unlock()
+}
+
+func (s *Server) sessionList() []*session {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	sessions := make([]*session, 0, len(s.sessions))
+	for _, ss := range s.sessions {
+		sessions = append(sessions, ss)
+	}
+
+	return sessions
+}
+
+// Notify sends a notification to every session, e.g.
+// notifications/tools/list_changed. Sessions without an open GET stream
+// receive it once they connect one.
+func (s *Server) Notify(method string, params any) {
+	data, err := encodeNotification(method, params)
+	if err != nil {
+		logInfof("mcp notification=%s error=%s", method, err.Error())
+		return
+	}
+
+	for _, ss := range s.sessionList() {
+		ss.publish(data)
+	}
+}
+
+func (s *Server) notifySession(id string, method string, params any) {
+	ss := s.session(id)
+	if ss == nil {
+		return
+	}
+
+	data, err := encodeNotification(method, params)
+	if err != nil {
+		logInfof("mcp notification=%s error=%s", method, err.Error())
+		return
+	}
+
+	ss.publish(data)
+}
+
+// serveStream serves the GET stream for server-to-client messages.
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	if s.stateless || !acceptsEventStream(r) {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "mcp server does not offer an event stream", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ss, ok := s.requestSession(w, r, true)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastId, err := strconv.ParseUint(r.Header.Get(headerLastEventId), 10, 64)
+	stream, replay := ss.attach(lastId, err == nil)
+	defer ss.detach(stream)
+
+	writeEventStreamHeader(w, ss.id)
+	flusher.Flush()
+
+	for _, event := range replay {
+		if writeEvent(w, event) != nil {
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	timer := time.NewTicker(sseKeepalive)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ss.closed:
+			return
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+
+			if writeEvent(w, event) != nil {
+				return
+			}
+
+			flusher.Flush()
+		case <-timer.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// serveDelete ends a session.
+func (s *Server) serveDelete(w http.ResponseWriter, r *http.Request) {
+	if s.stateless {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "mcp server has no sessions", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ss, ok := s.requestSession(w, r, true)
+	if !ok {
+		return
+	}
+
+	if !s.removeSession(ss.id) {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requestSession resolves the Mcp-Session-Id header. Requests without the
+// header are served statelessly unless required is set. With WithOAuth a
+// session only answers to the subject that initialized it, anyone else gets
+// the same 404 as for an unknown id.
+func (s *Server) requestSession(w http.ResponseWriter, r *http.Request, required bool) (*session, bool) {
+	id := r.Header.Get(headerSessionId)
+	if id == "" {
+		if required {
+			http.Error(w, "missing "+headerSessionId, http.StatusBadRequest)
+			return nil, false
+		}
+
+		return nil, true
+	}
+
+	ss := s.session(id)
+	if ss == nil || (s.oauth != nil && ss.subject != requestPrincipal(r).Subject) {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return ss, true
+}
+
+// postStream answers a POST request. It starts as a plain JSON response and
+// switches to an SSE stream once the handler sends a related notification or
+// request, provided the client accepts text/event-stream.
+type postStream struct {
+	w       http.ResponseWriter
+	session *session
+	sse     bool
+
+	mu      sync.Mutex
+	id      string
+	started bool
+	done    bool
+}
+
+func newPostStream(w http.ResponseWriter, r *http.Request, ss *session) *postStream {
+	_, flush := w.(http.Flusher)
+	return &postStream{w: w, session: ss, sse: flush && acceptsEventStream(r)}
+}
+
+func (p *postStream) send(data []byte) error {
+	if !p.sse {
+		return ErrStreamUnavailable
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.done {
+		return ErrStreamUnavailable
+	}
+
+	return p.writeLocked(data)
+}
+
+func (p *postStream) writeLocked(data []byte) error {
+	if !p.started {
+		p.started = true
+		if p.session != nil {
+			p.id = p.session.newStream()
+		}
+
+		writeEventStreamHeader(p.w, "")
+	}
+
+	event := sseEvent{data: data}
+	if p.session != nil {
+		event = p.session.record(p.id, data)
+	}
+
+	err := writeEvent(p.w, event)
+	p.w.(http.Flusher).Flush()
+	return err
+}
+
+// finish writes the response, as the last SSE event when the stream started.
+func (p *postStream) finish(res any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done = true
+	if !p.started {
+		writeRPC(p.w, res)
+		return
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+
+	_ = p.writeLocked(data)
+}
+
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		if strings.Contains(accept, contentTypeEventStream) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeEventStreamHeader(w http.ResponseWriter, sessionId string) {
+	if sessionId != "" {
+		w.Header().Set(headerSessionId, sessionId)
+	}
+
+	w.Header().Set("Content-Type", contentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeEvent(w http.ResponseWriter, event sseEvent) error {
+	var err error
+	if event.id > 0 {
+		_, err = fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", event.id, event.data)
+	} else {
+		_, err = fmt.Fprintf(w, "event: message\ndata: %s\n\n", event.data)
+	}
+
+	return err
+}
+
+func encodeNotification(method string, params any) ([]byte, error) {
+	return json.Marshal(rpcNotification{JSONRPC: jsonrpcVersion, Method: method, Params: params})
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
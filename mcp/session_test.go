@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestSessionLifecycle(t *testing.T) {
+	server := NewServer(nil)
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	res := sessionPost(t, ts.URL, "", "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	id := res.Header.Get(headerSessionId)
+	_ = res.Body.Close()
+	require.NotEmpty(t, id)
+
+	res = sessionPost(t, ts.URL, id, "", rpcRequestBody{JSONRPC: jsonrpcVersion, Method: methodNotificationsInitialized})
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+
+	res = sessionPost(t, ts.URL, "missing", "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodPing})
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL, nil)
+	req.Header.Set(headerSessionId, id)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res = sessionPost(t, ts.URL, id, "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodPing})
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestSessionStream(t *testing.T) {
+	server := NewServer(nil)
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	res := sessionPost(t, ts.URL, "", "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	id := res.Header.Get(headerSessionId)
+	_ = res.Body.Close()
+
+	// Queued until the GET stream connects.
+	server.Tool("late.tool", Tool{Handler: func(ctx *Context) {}})
+
+	stream := sessionStream(t, ts.URL, id, "")
+	defer stream.Body.Close()
+	require.Equal(t, contentTypeEventStream, stream.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(stream.Body)
+	_, data := readEvent(t, reader)
+	require.Equal(t, methodNotificationsToolsListChanged, gjson.Get(data, "method").String())
+
+	server.ResourceTemplate("stats://{name}", Resource{Handler: func(ctx *Context) {}})
+	_, data = readEvent(t, reader)
+	require.Equal(t, methodNotificationsResourcesListChanged, gjson.Get(data, "method").String())
+
+	res = sessionPost(t, ts.URL, id, "", rpcRequestBody{
+		JSONRPC: jsonrpcVersion,
+		ID:      2,
+		Method:  methodResourcesSubscribe,
+		Params:  resourceParams{URI: "stats://cpu"},
+	})
+	_ = res.Body.Close()
+
+	require.True(t, server.ResourceUpdated("stats://cpu"))
+	_, data = readEvent(t, reader)
+	require.Equal(t, methodNotificationsResourceUpdated, gjson.Get(data, "method").String())
+	require.Equal(t, "stats://cpu", gjson.Get(data, "params.uri").String())
+}
+
+func TestPostStream(t *testing.T) {
+	server := NewServer(nil)
+	server.Tool("steps", Tool{
+		Handler: func(ctx *Context) {
+			err1 := ctx.Notify("notifications/message", map[string]any{"data": "step 1"})
+			err2 := ctx.Notify("notifications/message", map[string]any{"data": "step 2"})
+			ctx.Send(errors.Join(err1, err2) == nil)
+		},
+	})
+
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	res := sessionPost(t, ts.URL, "", "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	id := res.Header.Get(headerSessionId)
+	_ = res.Body.Close()
+
+	call := rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 2, Method: methodToolsCall, Params: toolCallParams{Name: "steps"}}
+	res = sessionPost(t, ts.URL, id, contentTypeJSON+", "+contentTypeEventStream, call)
+	defer res.Body.Close()
+	require.Equal(t, contentTypeEventStream, res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+	first, data := readEvent(t, reader)
+	require.Equal(t, "step 1", gjson.Get(data, "params.data").String())
+	_, data = readEvent(t, reader)
+	require.Equal(t, "step 2", gjson.Get(data, "params.data").String())
+	_, data = readEvent(t, reader)
+	require.True(t, gjson.Get(data, "result.structuredContent").Bool())
+
+	// Resume the same response stream with Last-Event-ID.
+	stream := sessionStream(t, ts.URL, id, first)
+	defer stream.Body.Close()
+
+	reader = bufio.NewReader(stream.Body)
+	_, data = readEvent(t, reader)
+	require.Equal(t, "step 2", gjson.Get(data, "params.data").String())
+	_, data = readEvent(t, reader)
+	require.Equal(t, int64(2), gjson.Get(data, "id").Int())
+
+	// Plain JSON when the client does not accept an event stream.
+	body, status := postRPC(t, server, "", call)
+	require.Equal(t, http.StatusOK, status)
+	require.False(t, gjson.Get(body, "result.structuredContent").Bool())
+}
+
+func TestStateless(t *testing.T) {
+	server := NewServer(nil, WithStateless())
+	ts := httptest.NewServer(server.HTTPHandler())
+	defer ts.Close()
+
+	res := sessionPost(t, ts.URL, "", "", rpcRequestBody{JSONRPC: jsonrpcVersion, ID: 1, Method: methodInitialize})
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Empty(t, res.Header.Get(headerSessionId))
+
+	stream := sessionStream(t, ts.URL, "any", "")
+	_ = stream.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, stream.StatusCode)
+}
+
+func sessionPost(t *testing.T, url, id, accept string, body rpcRequestBody) *http.Response {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentTypeJSON)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if id != "" {
+		req.Header.Set(headerSessionId, id)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return res
+}
+
+func sessionStream(t *testing.T, url, id, lastEventId string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", contentTypeEventStream)
+	req.Header.Set(headerSessionId, id)
+	if lastEventId != "" {
+		req.Header.Set(headerLastEventId, lastEventId)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return res
+}
+
+// readEvent reads the next SSE event and returns its id and data.
+func readEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	t.Helper()
+
+	var id, data string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && data != "":
+			return id, data
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// maxStdioMessage bounds a single newline-delimited message read from stdin.
+const maxStdioMessage = 16 << 20
+
+// ServeStdio serves the MCP stdio transport: newline-delimited JSON-RPC
+// messages are read from in and responses and notifications are written to
+// out, e.g.
+//
+//	err := server.ServeStdio(ctx, os.Stdin, os.Stdout)
+//
+// The connection is a single session, so server notifications, resource
+// subscriptions and the log level work as they do over HTTP. Requests run
+// concurrently so notifications/cancelled can reach a running tool.
+// Context.Request is nil for stdio calls.
+//
+// Nothing else may write to out, make sure logs go to stderr. ServeStdio
+// returns when in reaches EOF or ctx is done.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	write := func(data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		_, err := out.Write(data)
+		if err == nil {
+			_, err = out.Write([]byte{'\n'})
+		}
+
+		return err
+	}
+
+	ss := s.newSession("")
+	defer s.removeSession(ss.id)
+
+	stream, _ := ss.attach(0, false)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ss.closed:
+				return
+			case event, ok := <-stream:
+				if !ok {
+					return
+				}
+
+				_ = write(event.data)
+			}
+		}
+	}()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64<<10), maxStdioMessage)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		readErr <- scanner.Err()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case line := <-lines:
+			if len(line) == 0 {
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				res := s.serve(line, func() *exchange {
+					return &exchange{ctx: ctx, session: ss, transport: TransportStdio, send: write}
+				})
+				if res == nil {
+					return
+				}
+
+				data, err := json.Marshal(res)
+				if err == nil {
+					err = write(data)
+				}
+				if err != nil {
+					logInfof("mcp stdio write error=%s", err.Error())
+				}
+			}()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestBatch(t *testing.T) {
+	server := NewServer(nil)
+	server.Tool("echo", Tool{
+		InputSchema: SchemaFor[echoRequest](),
+		Handler: func(ctx *Context) {
+			ctx.Send(ctx.Get("text"))
+		},
+	})
+
+	body, status := postRawRPC(t, server, "", `[
+		{"jsonrpc":"2.0","id":1,"method":"ping"},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}},
+		{"jsonrpc":"1.0","id":3,"method":"ping"}
+	]`)
+
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(3), gjson.Get(body, "#").Int())
+	require.Equal(t, int64(1), gjson.Get(body, "0.id").Int())
+	require.Equal(t, "hi", gjson.Get(body, "1.result.structuredContent").String())
+	require.Equal(t, int64(rpcErrorInvalidRequest), gjson.Get(body, "2.error.code").Int())
+
+	_, status = postRawRPC(t, server, "", `[{"jsonrpc":"2.0","method":"notifications/initialized"}]`)
+	require.Equal(t, http.StatusAccepted, status)
+
+	body, _ = postRawRPC(t, server, "", `[]`)
+	require.Equal(t, int64(rpcErrorInvalidRequest), gjson.Get(body, "error.code").Int())
+
+	body, _ = postRawRPC(t, server, "", `{"jsonrpc":`)
+	require.Equal(t, int64(rpcErrorParseError), gjson.Get(body, "error.code").Int())
+}
+
+func TestServeStdio(t *testing.T) {
+	server := NewServer(nil)
+	server.Tool("echo", Tool{
+		InputSchema: SchemaFor[echoRequest](),
+		Handler: func(ctx *Context) {
+			_ = ctx.Progress(1, 1, "")
+			ctx.Send(ctx.Get("text"))
+		},
+	})
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.ServeStdio(context.Background(), inReader, outWriter)
+	}()
+
+	lines := bufio.NewReader(outReader)
+	send := func(line string) {
+		_, err := io.WriteString(inWriter, line+"\n")
+		require.NoError(t, err)
+	}
+	read := func() string {
+		line, err := lines.ReadString('\n')
+		require.NoError(t, err)
+		return strings.TrimSpace(line)
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	require.Equal(t, DefaultProtocolVersion, gjson.Get(read(), "result.protocolVersion").String())
+
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"},"_meta":{"progressToken":7}}}`)
+	line := read()
+	require.Equal(t, methodNotificationsProgress, gjson.Get(line, "method").String())
+	require.Equal(t, int64(7), gjson.Get(line, "params.progressToken").Int())
+	require.Equal(t, "hi", gjson.Get(read(), "result.structuredContent").String())
+
+	// Server notifications reach the stdio session.
+	server.Prompt("late.prompt", Prompt{Handler: func(ctx *Context) {}})
+	require.Equal(t, methodNotificationsPromptsListChanged, gjson.Get(read(), "method").String())
+
+	send(`[{"jsonrpc":"2.0","id":3,"method":"ping"},{"jsonrpc":"2.0","id":4,"method":"ping"}]`)
+	require.Equal(t, int64(2), gjson.Get(read(), "#").Int())
+
+	require.NoError(t, inWriter.Close())
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeStdio did not return after EOF")
+	}
+
+	require.Empty(t, server.sessionList())
+}
+
+type echoRequest struct {
+	Text string `json:"text"`
+}
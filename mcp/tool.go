@@ -7,16 +7,29 @@ type HandlerFunc func(ctx *Context)
 type Tool struct {
 	Description string
 	InputSchema Schema
+
+	// OutputSchema declares the object the handler sends. It is advertised
+	// in tools/list and the sent data is validated against it, so clients can
+	// rely on structuredContent, e.g. SchemaFor[queryResponse](). Leave it
+	// empty for free-form results.
+	OutputSchema Schema
+
 	Policy      ToolPolicy
 	Annotations ToolAnnotations
 	Handler     HandlerFunc
 }
 
 type ToolPolicy struct {
-	ReadOnly             bool
+	ReadOnly bool
+
+	// Destructive and RequiresConfirmation tools only run once the call is
+	// confirmed, see WithApprover.
 	Destructive          bool
 	RequiresConfirmation bool
 	Timeout              time.Duration
+
+	// Scopes the access token must grant to call the tool, see WithOAuth.
+	Scopes []string
 }
 
 type ToolAnnotations struct {
@@ -45,3 +58,11 @@ func (t Tool) annotations() ToolAnnotations {
 
 	return annotations
 }
+
+func (t Tool) outputSchema() *Schema {
+	if t.OutputSchema.Type == "" {
+		return nil
+	}
+
+	return &t.OutputSchema
+}
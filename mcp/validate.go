@@ -0,0 +1,420 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	patternCache sync.Map // pattern -> *regexp.Regexp
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// checker validates decoded JSON values and names them in errors.
+type checker struct {
+	root  string // the whole value, e.g. "arguments"
+	field string // a nested value, e.g. "argument"
+}
+
+var (
+	argumentChecker = checker{root: "arguments", field: "argument"}
+	outputChecker   = checker{root: "output", field: "output field"}
+)
+
+// validateArguments checks arguments against schema and returns them with
+// missing properties filled from their defaults.
+func validateArguments(schema Schema, arguments json.RawMessage) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(arguments))
+	decoder.UseNumber()
+
+	var args map[string]any
+	if err := decoder.Decode(&args); err != nil || args == nil {
+		return nil, ErrInvalidArguments
+	}
+
+	changed := applyDefaults(schema, args)
+	if err := argumentChecker.validateValue("", schema, args); err != nil {
+		return nil, err
+	}
+	if !changed {
+		return arguments, nil
+	}
+
+	return json.Marshal(args)
+}
+
+// validateOutput checks the data a tool sent against its output schema.
+func validateOutput(schema Schema, data any) error {
+	return outputChecker.validateValue("", schema, normalizeJSON(data))
+}
+
+// applyDefaults sets missing object properties to their schema defaults and
+// reports whether anything changed.
+func applyDefaults(schema Schema, value any) bool {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return false
+	}
+
+	changed := false
+	for name, prop := range schema.Properties {
+		v, ok := object[name]
+		if !ok && prop.Default != nil {
+			object[name] = normalizeJSON(prop.Default)
+			changed = true
+			continue
+		}
+
+		if ok && applyDefaults(prop, v) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// validateValue validates a decoded JSON value. path names the value in
+// errors, e.g. "user.tags[1]", and is empty for the arguments object itself.
+func (c checker) validateValue(path string, schema Schema, value any) error {
+	if len(schema.OneOf) > 0 {
+		matched := 0
+		for _, option := range schema.OneOf {
+			if c.validateValue(path, option, value) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return c.errorf(path, "must match exactly one of %d schemas", len(schema.OneOf))
+		}
+	}
+
+	if err := c.validateType(path, schema.Type, value); err != nil {
+		return err
+	}
+
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+		return c.errorf(path, "must be one of %s", enumString(schema.Enum))
+	}
+
+	switch v := value.(type) {
+	case string:
+		return c.validateString(path, schema, v)
+	case json.Number:
+		return c.validateNumber(path, schema, v)
+	case []any:
+		return c.validateArray(path, schema, v)
+	case map[string]any:
+		return c.validateObject(path, schema, v)
+	}
+
+	return nil
+}
+
+func (c checker) validateType(path, t string, value any) error {
+	ok := true
+	switch t {
+	case "":
+		return nil
+	case "string":
+		_, ok = value.(string)
+	case "number":
+		_, ok = value.(json.Number)
+	case "integer":
+		n, isNumber := value.(json.Number)
+		ok = isNumber && isInteger(n)
+	case "boolean":
+		_, ok = value.(bool)
+	case "object":
+		_, ok = value.(map[string]any)
+	case "array":
+		_, ok = value.([]any)
+	case "null":
+		ok = value == nil
+	}
+
+	if ok {
+		return nil
+	}
+
+	switch t {
+	case "integer", "object", "array":
+		return c.errorf(path, "must be an %s", t)
+	default:
+		return c.errorf(path, "must be a %s", t)
+	}
+}
+
+// isInteger reports whether n has no fractional part, so 1.0 and 1e3 are
+// integers as JSON Schema defines them.
+func isInteger(n json.Number) bool {
+	if _, err := n.Int64(); err == nil {
+		return true
+	}
+
+	f, err := n.Float64()
+	return err == nil && f == math.Trunc(f)
+}
+
+func (c checker) validateString(path string, schema Schema, v string) error {
+	length := utf8.RuneCountInString(v)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		return c.errorf(path, "must be at least %d characters", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return c.errorf(path, "must be at most %d characters", *schema.MaxLength)
+	}
+
+	if schema.Pattern != "" {
+		re, err := compilePattern(schema.Pattern)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(v) {
+			return c.errorf(path, "must match pattern %q", schema.Pattern)
+		}
+	}
+
+	if schema.Format != "" && !validFormat(schema.Format, v) {
+		return c.errorf(path, "must be a valid %s", schema.Format)
+	}
+
+	return nil
+}
+
+func (c checker) validateNumber(path string, schema Schema, v json.Number) error {
+	n, err := v.Float64()
+	if err != nil {
+		return c.errorf(path, "must be a number")
+	}
+
+	if schema.Minimum != nil && n < *schema.Minimum {
+		return c.errorf(path, "must be >= %s", formatFloat(*schema.Minimum))
+	}
+	if schema.Maximum != nil && n > *schema.Maximum {
+		return c.errorf(path, "must be <= %s", formatFloat(*schema.Maximum))
+	}
+
+	return nil
+}
+
+func (c checker) validateArray(path string, schema Schema, v []any) error {
+	if schema.MinItems != nil && len(v) < *schema.MinItems {
+		return c.errorf(path, "must have at least %d items", *schema.MinItems)
+	}
+	if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+		return c.errorf(path, "must have at most %d items", *schema.MaxItems)
+	}
+
+	if schema.Items == nil {
+		return nil
+	}
+
+	for i, item := range v {
+		if err := c.validateValue(fmt.Sprintf("%s[%d]", path, i), *schema.Items, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c checker) validateObject(path string, schema Schema, v map[string]any) error {
+	for _, name := range schema.Required {
+		if _, ok := v[name]; !ok {
+			return fmt.Errorf("missing required %s %q", c.field, joinPath(path, name))
+		}
+	}
+
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := schema.Properties[name]
+		if !ok {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				return fmt.Errorf("unknown %s %q", c.field, joinPath(path, name))
+			}
+			continue
+		}
+
+		if err := c.validateValue(joinPath(path, name), prop, v[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkSchema reports schema mistakes at registration time instead of on the
+// first call.
+func checkSchema(path string, schema Schema) error {
+	if schema.Pattern != "" {
+		if _, err := compilePattern(schema.Pattern); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidSchema, schemaPath(path), err)
+		}
+	}
+
+	if schema.Minimum != nil && schema.Maximum != nil && *schema.Minimum > *schema.Maximum {
+		return fmt.Errorf("%w: %s: minimum is greater than maximum", ErrInvalidSchema, schemaPath(path))
+	}
+
+	for name, prop := range schema.Properties {
+		if err := checkSchema(joinPath(path, name), prop); err != nil {
+			return err
+		}
+	}
+
+	if schema.Items != nil {
+		if err := checkSchema(path+"[]", *schema.Items); err != nil {
+			return err
+		}
+	}
+
+	for _, option := range schema.OneOf {
+		if err := checkSchema(path, option); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+// validFormat checks the string formats Aqi understands. Unknown formats are
+// annotations only, as in JSON Schema.
+func validFormat(format, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, v)
+		return err == nil
+	case "time":
+		_, err := time.Parse(time.TimeOnly, v)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(v)
+		return err == nil && addr.Address == v
+	case "uri":
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		return uuidPattern.MatchString(v)
+	case "ipv4":
+		ip := net.ParseIP(v)
+		return ip != nil && ip.To4() != nil && !strings.Contains(v, ":")
+	case "ipv6":
+		ip := net.ParseIP(v)
+		return ip != nil && strings.Contains(v, ":")
+	default:
+		return true
+	}
+}
+
+func enumContains(values []any, value any) bool {
+	for _, v := range values {
+		if jsonEqual(normalizeJSON(v), value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func jsonEqual(a, b any) bool {
+	na, okA := a.(json.Number)
+	nb, okB := b.(json.Number)
+	if okA && okB {
+		fa, errA := na.Float64()
+		fb, errB := nb.Float64()
+		return errA == nil && errB == nil && fa == fb
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// normalizeJSON converts a Go value to the form produced by decoding JSON with
+// UseNumber, so it compares equal to decoded arguments.
+func normalizeJSON(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var out any
+	if decoder.Decode(&out) != nil {
+		return v
+	}
+
+	return out
+}
+
+func enumString(values []any) string {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Sprint(values)
+	}
+
+	return string(data)
+}
+
+func (c checker) errorf(path, format string, args ...any) error {
+	if path == "" {
+		return fmt.Errorf(c.root+" "+format, args...)
+	}
+
+	return fmt.Errorf(c.field+" %q "+format, append([]any{path}, args...)...)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func schemaPath(path string) string {
+	if path == "" {
+		return "schema"
+	}
+
+	return strconv.Quote(path)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -99,3 +99,63 @@ func Telemetry(provider telemetry.Provider) Option {
 		return nil
 	}
 }
+
+// WsServer 使用独立的websocket服务，默认使用 ws.Default()
+func WsServer(server *ws.Server) Option {
+	return func(config *AppConfig) error {
+		config.WsServer = server
+		return nil
+	}
+}
+
+// Admission 设置websocket连接准入策略
+func Admission(policy ws.AdmissionPolicy) Option {
+	return func(config *AppConfig) error {
+		config.Admission = &policy
+		return nil
+	}
+}
+
+// Heartbeat 设置websocket心跳和空闲断开策略
+func Heartbeat(policy ws.HeartbeatPolicy) Option {
+	return func(config *AppConfig) error {
+		config.Heartbeat = &policy
+		return nil
+	}
+}
+
+// SessionPolicy 设置 appId 的多端登录策略，appId 为空时设置默认策略
+func SessionPolicy(appId string, policy ws.SessionPolicy) Option {
+	return func(config *AppConfig) error {
+		if config.SessionPolicies == nil {
+			config.SessionPolicies = map[string]ws.SessionPolicy{}
+		}
+
+		config.SessionPolicies[appId] = policy
+		return nil
+	}
+}
+
+// Presence 设置在线状态策略，如自动离开时间和可见范围
+func Presence(policy ws.PresencePolicy) Option {
+	return func(config *AppConfig) error {
+		config.Presence = &policy
+		return nil
+	}
+}
+
+// BanStore 设置用户封禁存储，默认保存在内存中
+func BanStore(store ws.BanStore) Option {
+	return func(config *AppConfig) error {
+		config.BanStore = store
+		return nil
+	}
+}
+
+// LangStore 设置语言包存储，替换默认的文件存储，store 实现 ws.LangWatcher 时支持热更新
+func LangStore(store ws.LangStore) Option {
+	return func(config *AppConfig) error {
+		config.LangStore = store
+		return nil
+	}
+}
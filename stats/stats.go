@@ -43,6 +43,11 @@ type Stats struct {
 	GuestCount  int `json.json:"guestCount"` // Visitors
 	Connections int `json:"connections"`     // Current process's network connections
 
+	WsConnections int              `json:"wsConnections"` // Admitted websocket, SSE and long-polling connections
+	WsRejected    map[string]int64 `json:"wsRejected"`    // Rejected connections by reason
+
+	Tenants map[uint]*ws.TenantStats `json:"tenants,omitempty"` // Online users and connections per tenant
+
 	SentRate float64 `json:"sentRate"` // Sending rate KB/s
 	RecvRate float64 `json:"recvRate"` // Receiving rate KB/s
 
@@ -89,13 +94,17 @@ func (sc *Collector) Collect(interval time.Duration) {
 func (sc *Collector) doCollect(interval time.Duration) {
 	currentStats := Stats{}
 
-	if ws.Hub == nil {
-		return
-	}
+	hub := ws.Default().Hub()
 
 	// User data
-	currentStats.LoginCount = ws.Hub.LoginCount
-	currentStats.GuestCount = ws.Hub.GuestCount
+	currentStats.LoginCount = hub.LoginCount
+	currentStats.GuestCount = hub.GuestCount
+
+	// Admission data
+	admission := ws.Default().AdmissionStats()
+	currentStats.WsConnections = admission.Active
+	currentStats.WsRejected = admission.Rejected
+	currentStats.Tenants = hub.TenantStats()
 
 	// Get CPU usage rate
 	cpuPercentages, err := cpu.Percent(interval, false)
@@ -209,7 +218,7 @@ func (sc *Collector) doCollect(interval time.Duration) {
 	sc.mu.Unlock()
 
 	// Publish data
-	ws.Hub.PubSub.Pub("sys:status", currentStats)
+	hub.PubSub.Pub("sys:status", currentStats)
 }
 
 // GetStats returns all the collected statistical data
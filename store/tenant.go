@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type tenantCtxKey struct{}
+
+// WithTenant 将租户ID写入 context，配合 RegisterTenant 使用
+//
+//	db.WithContext(store.WithTenant(ctx, 1)).Find(&orders)
+func WithTenant(ctx context.Context, tenantId uint) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, tenantId)
+}
+
+// TenantFrom 读取 context 中的租户ID
+func TenantFrom(ctx context.Context) (uint, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+
+	tenantId, ok := ctx.Value(tenantCtxKey{}).(uint)
+	return tenantId, ok
+}
+
+// TenantScope 按租户过滤查询，column 为租户字段名
+//
+//	db.Scopes(store.TenantScope("tenant_id", 1)).Find(&orders)
+func TenantScope(column string, tenantId uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: tenantId})
+	}
+}
+
+// RegisterTenant 注册gorm回调，context 中带有租户ID时自动按租户隔离
+//
+// 查询、更新和删除加上租户条件，创建时写入租户ID，只处理包含 column 字段的模型。
+// 可在 Callback 中注册:
+//
+//	store.DB("mysql").Callback(func(db *gorm.DB) {
+//		_ = store.RegisterTenant(db, "tenant_id")
+//	})
+func RegisterTenant(db *gorm.DB, column string) error {
+	cb := db.Callback()
+	err := cb.Create().Before("gorm:create").Register("aqi:tenant_create", tenantCreate(column))
+	if err != nil {
+		return err
+	}
+
+	err = cb.Query().Before("gorm:query").Register("aqi:tenant_query", tenantWhere(column, false))
+	if err != nil {
+		return err
+	}
+
+	err = cb.Row().Before("gorm:row").Register("aqi:tenant_row", tenantWhere(column, false))
+	if err != nil {
+		return err
+	}
+
+	err = cb.Update().After("gorm:setup_reflect_value").Before("gorm:update").Register("aqi:tenant_update", tenantWhere(column, true))
+	if err != nil {
+		return err
+	}
+
+	return cb.Delete().Before("gorm:delete").Register("aqi:tenant_delete", tenantWhere(column, true))
+}
+
+func tenantField(db *gorm.DB, column string) (*schema.Field, uint, bool) {
+	tenantId, ok := TenantFrom(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return nil, 0, false
+	}
+
+	field := db.Statement.Schema.LookUpField(column)
+	return field, tenantId, field != nil
+}
+
+func tenantWhere(column string, write bool) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		field, tenantId, ok := tenantField(db, column)
+		if !ok {
+			return
+		}
+
+		//没有条件的更新和删除交给gorm拒绝，不因租户条件变成整个租户的批量操作
+		if write && !hasConditions(db) {
+			return
+		}
+
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantId},
+		}})
+	}
+}
+
+func tenantCreate(column string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		field, tenantId, ok := tenantField(db, column)
+		if !ok {
+			return
+		}
+
+		ctx := db.Statement.Context
+		rv := reflect.Indirect(db.Statement.ReflectValue)
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				_ = field.Set(ctx, reflect.Indirect(rv.Index(i)), tenantId)
+			}
+		case reflect.Struct:
+			_ = field.Set(ctx, rv, tenantId)
+		}
+	}
+}
+
+// hasConditions 更新和删除是否带有条件，包括gorm根据主键生成的条件
+func hasConditions(db *gorm.DB) bool {
+	if _, ok := db.Statement.Clauses["WHERE"]; ok || db.AllowGlobalUpdate {
+		return true
+	}
+
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return false
+	}
+
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	case reflect.Struct:
+		_, zero := field.ValueOf(db.Statement.Context, rv)
+		return !zero
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type tenantOrder struct {
+	Id       uint
+	TenantId uint
+	Name     string
+}
+
+func TestRegisterTenant(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, RegisterTenant(db, "tenant_id"))
+	require.NoError(t, db.AutoMigrate(&tenantOrder{}))
+
+	t1 := db.WithContext(WithTenant(context.Background(), 1))
+	t2 := db.WithContext(WithTenant(context.Background(), 2))
+
+	//创建时写入租户ID，不允许写入其它租户
+	a := &tenantOrder{Name: "a", TenantId: 2}
+	require.NoError(t, t1.Create(a).Error)
+	require.Equal(t, uint(1), a.TenantId)
+	require.NoError(t, t2.Create(&[]tenantOrder{{Name: "b"}, {Name: "c"}}).Error)
+
+	var list []tenantOrder
+	require.NoError(t, t1.Find(&list).Error)
+	require.Len(t, list, 1)
+
+	require.NoError(t, t2.Where("name = ? OR name = ?", "a", "b").Find(&list).Error)
+	require.Len(t, list, 1)
+	require.Equal(t, "b", list[0].Name)
+
+	var count int64
+	require.NoError(t, db.Model(&tenantOrder{}).Count(&count).Error)
+	require.Equal(t, int64(3), count)
+
+	//按主键更新和删除其它租户的数据不生效
+	res := t2.Model(a).Update("name", "x")
+	require.NoError(t, res.Error)
+	require.Equal(t, int64(0), res.RowsAffected)
+
+	res = t2.Delete(&tenantOrder{}, a.Id)
+	require.NoError(t, res.Error)
+	require.Equal(t, int64(0), res.RowsAffected)
+
+	//没有条件的批量更新依然被拒绝
+	require.ErrorIs(t, t1.Model(&tenantOrder{}).Update("name", "x").Error, gorm.ErrMissingWhereClause)
+
+	require.NoError(t, db.Scopes(TenantScope("tenant_id", 2)).Find(&list).Error)
+	require.Len(t, list, 2)
+}
@@ -0,0 +1,203 @@
+package i18n
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const maxLocalizeDepth = 32
+
+var (
+	textType      = reflect.TypeOf(Text{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	// reflect.Type -> bool 类型中是否可能包含 Text
+	textTypeCache sync.Map
+)
+
+// Localize 返回 v 的副本，其中所有 Text 字段都设置为 lng 输出
+//
+// 不包含 Text 的数据原样返回；json:"-" 字段和自定义 MarshalJSON 的类型不会处理
+func Localize(v any, lng string) any {
+	if v == nil || lng == "" {
+		return v
+	}
+
+	// any、H 等类型只能按值判断是否包含 Text，没有时不复制
+	rv := reflect.ValueOf(v)
+	if !mayContainText(rv.Type()) || !hasText(rv, 0) {
+		return v
+	}
+
+	return localize(rv, lng, 0).Interface()
+}
+
+func localize(v reflect.Value, lng string, depth int) reflect.Value {
+	t := v.Type()
+	if t == textType {
+		return reflect.ValueOf(v.Interface().(Text).In(lng))
+	}
+
+	if depth > maxLocalizeDepth || !mayContainText(t) {
+		return v
+	}
+
+	depth++
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+
+		out := reflect.New(t.Elem())
+		out.Elem().Set(localize(v.Elem(), lng, depth))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		out := reflect.New(t).Elem()
+		out.Set(localize(v.Elem(), lng, depth))
+		return out
+	case reflect.Struct:
+		out := reflect.New(t).Elem()
+		out.Set(v)
+		for i := 0; i < t.NumField(); i++ {
+			if localizeField(t.Field(i)) {
+				out.Field(i).Set(localize(v.Field(i), lng, depth))
+			}
+		}
+
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		out := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(localize(v.Index(i), lng, depth))
+		}
+
+		return out
+	case reflect.Array:
+		out := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(localize(v.Index(i), lng, depth))
+		}
+
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		out := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), localize(iter.Value(), lng, depth))
+		}
+
+		return out
+	}
+
+	return v
+}
+
+// hasText 按实际值检查是否包含 Text
+func hasText(v reflect.Value, depth int) bool {
+	if !v.IsValid() {
+		return false
+	}
+
+	t := v.Type()
+	if t == textType {
+		return true
+	}
+
+	if depth > maxLocalizeDepth || !mayContainText(t) {
+		return false
+	}
+
+	depth++
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return !v.IsNil() && hasText(v.Elem(), depth)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if localizeField(t.Field(i)) && hasText(v.Field(i), depth) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if hasText(v.Index(i), depth) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if hasText(iter.Value(), depth) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func mayContainText(t reflect.Type) bool {
+	if cached, ok := textTypeCache.Load(t); ok {
+		return cached.(bool)
+	}
+
+	res := containsText(t, map[reflect.Type]bool{})
+	textTypeCache.Store(t, res)
+	return res
+}
+
+func containsText(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if t == textType {
+		return true
+	}
+
+	if visiting[t] {
+		return false
+	}
+
+	if t.Kind() != reflect.Pointer && (t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)) {
+		return false
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return containsText(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if localizeField(f) && containsText(f.Type, visiting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func localizeField(f reflect.StructField) bool {
+	if !f.IsExported() {
+		return false
+	}
+
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name != "-"
+}
@@ -0,0 +1,187 @@
+package i18n
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultLanguage 指定语言没有内容时的回退语言
+var DefaultLanguage = "zh"
+
+// Text 多语言字段，数据库中以 {"zh":"...","en":"..."} JSON 保存
+//
+// 设置语言后（In 或 Localize）序列化为该语言的字符串，否则输出全部语言
+type Text struct {
+	Values map[string]string
+
+	lng string
+}
+
+func NewText(values map[string]string) Text {
+	return Text{Values: values}
+}
+
+// In 返回指定输出语言的副本
+func (t Text) In(lng string) Text {
+	t.lng = lng
+	return t
+}
+
+// Lang 当前输出语言
+func (t Text) Lang() string {
+	return t.lng
+}
+
+// Get 获取指定语言的内容
+//
+// 依次回退到主语言（zh-CN -> zh）、DefaultLanguage 及任意一个非空值
+func (t Text) Get(lng string) string {
+	if v := t.Values[lng]; v != "" {
+		return v
+	}
+
+	if i := strings.IndexAny(lng, "-_"); i > 0 {
+		if v := t.Values[lng[:i]]; v != "" {
+			return v
+		}
+	}
+
+	if v := t.Values[DefaultLanguage]; v != "" {
+		return v
+	}
+
+	keys := make([]string, 0, len(t.Values))
+	for k := range t.Values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		if t.Values[k] != "" {
+			return t.Values[k]
+		}
+	}
+
+	return ""
+}
+
+// Set 设置指定语言的内容
+func (t *Text) Set(lng, value string) {
+	if t.Values == nil {
+		t.Values = make(map[string]string)
+	}
+
+	t.Values[lng] = value
+}
+
+func (t Text) String() string {
+	if t.lng != "" {
+		return t.Get(t.lng)
+	}
+
+	return t.Get(DefaultLanguage)
+}
+
+func (t Text) MarshalJSON() ([]byte, error) {
+	if t.lng != "" {
+		return json.Marshal(t.Get(t.lng))
+	}
+
+	if t.Values == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(t.Values)
+}
+
+// UnmarshalJSON 支持对象和字符串，字符串作为 DefaultLanguage 的内容
+func (t *Text) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		t.Values = nil
+		return nil
+	}
+
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+
+		t.Values = map[string]string{DefaultLanguage: s}
+		return nil
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	t.Values = values
+	return nil
+}
+
+func (t Text) Value() (driver.Value, error) {
+	if t.Values == nil {
+		return "{}", nil
+	}
+
+	d, err := json.Marshal(t.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(d), nil
+}
+
+func (t *Text) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		t.Values = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("failed to scan i18n text: %v", value)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		t.Values = nil
+		return nil
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+
+	t.Values = values
+	return nil
+}
+
+func (Text) GormDataType() string {
+	return "json"
+}
+
+func (Text) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "mysql", "sqlite":
+		return "JSON"
+	case "sqlserver":
+		return "NVARCHAR(MAX)"
+	case "postgres":
+		return "JSONB"
+	}
+
+	return ""
+}
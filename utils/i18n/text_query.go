@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TextQuery 按语言查询 Text 字段，支持 MySQL、SQLite、SQL Server 和 PostgreSQL 的 JSON 函数
+//
+//	db.Where(i18n.TextEq("name", "en", "Apple")).Find(&products)
+//	db.Where(i18n.TextLike("name", "zh", "%苹果%")).Order(i18n.TextOrder("name", "zh", false)).Find(&products)
+type TextQuery struct {
+	column string
+	lng    string
+	op     string
+	value  any
+}
+
+// TextEq 指定语言的内容等于 value
+func TextEq(column, lng string, value any) *TextQuery {
+	return &TextQuery{column: column, lng: lng, op: "=", value: value}
+}
+
+// TextLike 指定语言的内容匹配 pattern
+func TextLike(column, lng, pattern string) *TextQuery {
+	return &TextQuery{column: column, lng: lng, op: "LIKE", value: pattern}
+}
+
+// TextExtract 取出指定语言的内容，可用于 Select、Order 等
+func TextExtract(column, lng string) *TextQuery {
+	return &TextQuery{column: column, lng: lng}
+}
+
+// TextOrder 按指定语言的内容排序
+func TextOrder(column, lng string, desc bool) clause.OrderBy {
+	sql := "?"
+	if desc {
+		sql = "? DESC"
+	}
+
+	return clause.OrderBy{
+		Expression: clause.Expr{SQL: sql, Vars: []any{TextExtract(column, lng)}},
+	}
+}
+
+func (q *TextQuery) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+
+	switch stmt.Dialector.Name() {
+	case "mysql":
+		builder.WriteString("JSON_UNQUOTE(JSON_EXTRACT(")
+		builder.WriteQuoted(q.column)
+		builder.WriteByte(',')
+		builder.AddVar(stmt, textPath(q.lng))
+		builder.WriteString("))")
+	case "postgres":
+		builder.WriteByte('(')
+		builder.WriteQuoted(q.column)
+		builder.WriteString(" ->> ")
+		builder.AddVar(stmt, q.lng)
+		builder.WriteByte(')')
+	case "sqlserver":
+		builder.WriteString("JSON_VALUE(")
+		builder.WriteQuoted(q.column)
+		builder.WriteByte(',')
+		builder.AddVar(stmt, textPath(q.lng))
+		builder.WriteByte(')')
+	default:
+		builder.WriteString("JSON_EXTRACT(")
+		builder.WriteQuoted(q.column)
+		builder.WriteByte(',')
+		builder.AddVar(stmt, textPath(q.lng))
+		builder.WriteByte(')')
+	}
+
+	if q.op != "" {
+		builder.WriteByte(' ')
+		builder.WriteString(q.op)
+		builder.WriteByte(' ')
+		builder.AddVar(stmt, q.value)
+	}
+}
+
+func textPath(lng string) string {
+	return `$."` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(lng) + `"`
+}
@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type product struct {
+	Id   uint `gorm:"primaryKey"`
+	Name Text
+	Desc *Text
+}
+
+func TestTextJSON(t *testing.T) {
+	name := NewText(map[string]string{"zh": "苹果", "en": "Apple"})
+
+	d, err := json.Marshal(name)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"zh":"苹果","en":"Apple"}`, string(d))
+
+	d, err = json.Marshal(name.In("en"))
+	require.NoError(t, err)
+	require.Equal(t, `"Apple"`, string(d))
+
+	require.Equal(t, "Apple", name.Get("en-US"))
+	require.Equal(t, "苹果", name.Get("ja"))
+
+	var text Text
+	require.NoError(t, json.Unmarshal([]byte(`"香蕉"`), &text))
+	require.Equal(t, "香蕉", text.Values[DefaultLanguage])
+}
+
+func TestLocalize(t *testing.T) {
+	desc := NewText(map[string]string{"zh": "红色", "en": "Red"})
+	p := &product{Id: 1, Name: NewText(map[string]string{"zh": "苹果", "en": "Apple"}), Desc: &desc}
+
+	data := map[string]any{
+		"rows":  []*product{p},
+		"count": 1,
+	}
+
+	d, err := json.Marshal(Localize(data, "en"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"count":1,"rows":[{"Id":1,"Name":"Apple","Desc":"Red"}]}`, string(d))
+
+	// 原数据不受影响
+	require.Equal(t, "", p.Name.Lang())
+	require.Equal(t, "", p.Desc.Lang())
+
+	plain := struct{ A int }{A: 1}
+	require.Equal(t, plain, Localize(plain, "en"))
+
+	// 不含 Text 的 map 原样返回，不复制
+	m := map[string]any{"count": 1, "rows": []any{"a"}}
+	require.Equal(t, reflect.ValueOf(m).Pointer(), reflect.ValueOf(Localize(m, "en")).Pointer())
+}
+
+func TestTextQuery(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&product{}))
+
+	require.NoError(t, db.Create(&[]product{
+		{Name: NewText(map[string]string{"zh": "苹果", "en": "Apple"})},
+		{Name: NewText(map[string]string{"zh": "香蕉", "en": "Banana"})},
+		{Name: NewText(map[string]string{"zh": "青苹果", "en": "Green apple"})},
+	}).Error)
+
+	var p product
+	require.NoError(t, db.Where(TextEq("name", "en", "Banana")).First(&p).Error)
+	require.Equal(t, "香蕉", p.Name.Get("zh"))
+
+	var list []product
+	require.NoError(t, db.Where(TextLike("name", "zh", "%苹果%")).Order(TextOrder("name", "en", true)).Find(&list).Error)
+	require.Len(t, list, 2)
+	require.Equal(t, "Green apple", list[0].Name.Get("en"))
+	require.Equal(t, "Apple", list[1].Name.Get("en"))
+}
+
+type postgresDialector struct {
+	gorm.Dialector
+}
+
+func (postgresDialector) Name() string {
+	return "postgres"
+}
+
+func TestTextQueryPostgres(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	db.Dialector = postgresDialector{db.Dialector}
+	stmt := &gorm.Statement{DB: db, Clauses: map[string]clause.Clause{}}
+	TextEq("name", "en", "Apple").Build(stmt)
+
+	require.Equal(t, "(`name` ->> ?) = ?", stmt.SQL.String())
+	require.Equal(t, []any{"en", "Apple"}, stmt.Vars)
+}
@@ -1,11 +1,14 @@
 package ws
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gobwas/ws"
@@ -20,19 +23,21 @@ type Client struct {
 	Hub            *Hubc
 	Conn           net.Conn
 	Send           chan []byte
-	Endpoint       string    //入口地址
-	OnceId         string    //临时ID，扫码登录等场景作为客户端唯一标识
-	ClientId       string    //客户端ID
-	Disconnecting  bool      //已被设置为断开状态（消息发送完之后断开连接）
-	SyncMsg        bool      //是否接收消息
-	LastMsgId      int       //最后一条消息ID
-	RequiredValid  bool      //人机验证标识
-	Validated      bool      //是否已验证
-	ValidExpiry    time.Time //验证有效期
-	ValidCacheData any       //验证相关缓存数据
-	AuthCode       string    //用于校验JWT中的code，如果相等识别为同一个用户的网络地址变更
-	ErrorCount     int       //错误次数
-	Closed         bool      //是否已经关闭
+	Endpoint       string      //入口地址
+	OnceId         string      //临时ID，扫码登录等场景作为客户端唯一标识
+	ClientId       string      //客户端ID
+	Disconnecting  atomic.Bool //已被设置为断开状态（消息发送完之后断开连接）
+	SyncMsg        bool        //是否接收消息
+	LastMsgId      int         //最后一条消息ID
+	RequiredValid  bool        //人机验证标识
+	Validated      bool        //是否已验证
+	ValidExpiry    time.Time   //验证有效期
+	ValidCacheData any         //验证相关缓存数据
+	AuthCode       string      //用于校验JWT中的code，如果相等识别为同一个用户的网络地址变更
+	ErrorCount     int         //错误次数
+	Closed         bool        //是否已经关闭，并发读取时使用 IsClosed
+	Transport      string      //传输方式 websocket/sse/polling
+	SessionId      string      //SSE和长轮询的会话ID
 
 	Limiter      *rate.Limiter //限速器
 	RequestQueue chan string   //处理队列
@@ -54,10 +59,24 @@ type Client struct {
 	IpLocation        string    //通过IP转换获得的地理位置
 	ConnectionTime    time.Time //连接时间
 	LastRequestTime   time.Time //最后请求时间
-	LastHeartbeatTime time.Time //最后发送心跳时间
-
-	mu   sync.RWMutex
-	Keys map[string]any
+	LastHeartbeatTime time.Time //最后心跳时间
+
+	mu         sync.RWMutex
+	dispatchMu sync.Mutex //SSE和长轮询客户端按顺序处理请求
+	release    func()     //断开后释放准入计数
+	writeMu    sync.Mutex
+	closeMu    sync.RWMutex //入队时持有读锁，关闭 Send 时持有写锁
+	closing    atomic.Bool
+	doneOnce   sync.Once
+	done       chan struct{}        //关闭时关闭，唤醒等待入队的发送方
+	lastMsg    atomic.Pointer[byte] //最后一条消息，发送完成后断开连接
+
+	heartbeat   HeartbeatPolicy
+	lastSeen    atomic.Int64 //最后收到数据的时间
+	lastRequest atomic.Int64 //最后请求action的时间
+	idleClosed  atomic.Bool
+	connId      atomic.Uint64
+	Keys        map[string]any
 
 	// recent logs ring buffer (last 100 items)
 	recentLogs  [100]string
@@ -68,25 +87,52 @@ type Client struct {
 // Reader 读取
 func (c *Client) Reader() {
 	defer func() {
-		c.Hub.Disconnect <- c
+		c.Hub.disconnect(c)
 	}()
 
+	controlHandler := wsutil.ControlFrameHandler(lockedConnWriter{c: c}, ws.StateServerSide)
+	rd := &wsutil.Reader{
+		Source:         c.Conn,
+		State:          ws.StateServerSide,
+		CheckUTF8:      true,
+		OnIntermediate: controlHandler,
+	}
+
+	c.touch()
 	for {
-		request, op, err := wsutil.ReadClientData(c.Conn)
+		hdr, err := rd.NextFrame()
 		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				c.closeIdle(IdleReasonPong)
+			}
+
 			c.Log("xx", "Error reading data", err.Error())
 			return
 		}
 
-		if op == ws.OpText && request != nil {
+		//收到任何帧（包括pong）都视为客户端存活
+		c.touch()
+		if hdr.OpCode.IsControl() {
+			err = controlHandler(hdr, rd)
+			if err != nil {
+				c.Log("xx", "Control frame", err.Error())
+				return
+			}
+
+			continue
+		}
+
+		request, err := io.ReadAll(rd)
+		if err != nil {
+			c.Log("xx", "Error reading data", err.Error())
+			return
+		}
+
+		if hdr.OpCode == ws.OpText && len(request) > 0 {
 			req := string(request)
 			c.Log("<-", req)
 			c.RequestQueue <- req
-		} else if op == ws.OpPing {
-			err = wsutil.WriteServerMessage(c.Conn, ws.OpPong, nil)
-			if err != nil {
-				c.Log("xx", "Reply pong", err.Error())
-			}
 		} else {
 			c.Log("xx", "Unrecognized action")
 		}
@@ -112,10 +158,10 @@ func (c *Client) Request() {
 
 // Write 发送
 func (c *Client) Write() {
-	timer := time.NewTicker(5 * time.Second)
+	timer := time.NewTicker(c.heartbeat.withDefaults().PingInterval)
 	defer func() {
 		timer.Stop()
-		c.Hub.Disconnect <- c
+		c.Hub.disconnect(c)
 	}()
 
 	for {
@@ -125,7 +171,7 @@ func (c *Client) Write() {
 				return
 			}
 
-			err := wsutil.WriteServerMessage(c.Conn, ws.OpText, msg)
+			err := c.writeFrame(ws.OpText, msg)
 			if err != nil {
 				c.Log("xx", "Send msg error", err.Error())
 				return
@@ -133,19 +179,24 @@ func (c *Client) Write() {
 
 			//如果设置为断开状态
 			//在消息发送完成后将断开与服务器的连接
-			if c.Disconnecting {
+			if c.sent(msg) {
 				return
 			}
 
 			c.Log("->", string(msg))
 		case <-timer.C:
-			err := wsutil.WriteServerMessage(c.Conn, ws.OpPing, []byte("ping"))
+			if reason := c.idleReason(); reason != "" {
+				c.closeIdle(reason)
+				return
+			}
+
+			err := c.writeFrame(ws.OpPing, []byte("ping"))
 			if err != nil {
 				c.Log("xx", "Error actively pinging the client", err.Error())
 				return
 			}
 
-			c.LastHeartbeatTime = time.Now()
+			c.LastHeartbeatTime = time.Unix(0, c.lastSeen.Load())
 			if c.User != nil {
 				c.User.LastHeartbeatTime = c.LastHeartbeatTime
 			}
@@ -162,7 +213,9 @@ func (c *Client) Log(symbol string, msg ...string) {
 		s = fmt.Sprintf("%s %s %s", c.IpAddressPort, symbol, s)
 	}
 
-	logger.SugarLog.Info(s)
+	if logger.SugarLog != nil {
+		logger.SugarLog.Info(s)
+	}
 
 	c.mu.Lock()
 	c.recentLogs[c.recentIdx] = fmt.Sprintf("%s %s", time.Now().Format(time.RFC3339), s)
@@ -173,16 +226,50 @@ func (c *Client) Log(symbol string, msg ...string) {
 	c.mu.Unlock()
 }
 
-// SendMsg 把消息加入发送队列
+// SendMsg 把消息加入发送队列，客户端关闭后丢弃
 func (c *Client) SendMsg(msg []byte) {
-	defer func() {
-		if err := recover(); err != nil {
-			c.Hub.Disconnect <- c
-			logger.SugarLog.Errorf("SendMsg recover error(%s): %s", c.IpAddressPort, err)
-		}
-	}()
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+
+	if c.closing.Load() {
+		return
+	}
+
+	select {
+	case c.Send <- msg:
+	case <-c.closed():
+	}
+}
+
+func (c *Client) closed() chan struct{} {
+	c.doneOnce.Do(func() {
+		c.done = make(chan struct{})
+	})
+
+	return c.done
+}
 
-	c.Send <- msg
+// IsClosed 客户端是否已关闭
+func (c *Client) IsClosed() bool {
+	return c.closing.Load()
+}
+
+// sendLast 发送最后一条消息，写出后断开连接
+func (c *Client) sendLast(msg []byte) {
+	if len(msg) > 0 {
+		c.lastMsg.Store(&msg[0])
+	}
+
+	c.SendMsg(msg)
+}
+
+// sent 消息已写出，返回是否需要断开连接
+func (c *Client) sent(msg []byte) bool {
+	if len(msg) > 0 && c.lastMsg.Load() == &msg[0] {
+		c.Disconnecting.Store(true)
+	}
+
+	return c.Disconnecting.Load()
 }
 
 // SendActionMsg 构造消息再发送
@@ -192,26 +279,26 @@ func (c *Client) SendActionMsg(a *Action) {
 
 // Close 关闭客户端
 func (c *Client) Close() {
-	defer func() {
-		if err := recover(); err != nil {
-			c.Log("xx", "recover!! -> ", fmt.Sprintf("%v", err))
-			return
-		}
-	}()
+	if !c.closing.CompareAndSwap(false, true) {
+		return
+	}
 
-	if !c.Closed {
-		//防止重复关闭
-		c.Closed = true
+	//新的发送直接返回，等待入队的发送被唤醒，已入队的消息保留
+	close(c.closed())
+	c.closeMu.Lock()
 
-		//关闭通道
-		close(c.Send)
+	//关闭通道
+	c.Closed = true
+	close(c.Send)
+	c.closeMu.Unlock()
 
-		//关闭网络连接
+	//关闭网络连接
+	if c.Conn != nil {
 		_ = c.Conn.Close()
-
-		//打印日志
-		c.Log("xx", fmt.Sprintf("Close client -> %s", c.IpAddressPort))
 	}
+
+	//打印日志
+	c.Log("xx", fmt.Sprintf("Close client -> %s", c.IpAddressPort))
 }
 
 func (c *Client) GetRecentLogs() []string {
@@ -236,3 +323,25 @@ func (c *Client) GetRecentLogs() []string {
 func (c *Client) MarshalJSON() ([]byte, error) {
 	return []byte("{}"), nil
 }
+
+// server 客户端所属的 Server，未关联时使用默认 Server
+func (c *Client) server() *Server {
+	if c.Hub != nil && c.Hub.server != nil {
+		return c.Hub.server
+	}
+
+	return Default()
+}
+
+var connSeq atomic.Uint64
+
+// ConnId 进程内唯一的连接ID，首次调用时分配
+func (c *Client) ConnId() uint64 {
+	id := c.connId.Load()
+	if id != 0 {
+		return id
+	}
+
+	c.connId.CompareAndSwap(0, connSeq.Add(1))
+	return c.connId.Load()
+}
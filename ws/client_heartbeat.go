@@ -0,0 +1,132 @@
+package ws
+
+import (
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// HeartbeatPolicy websocket心跳和空闲断开策略
+type HeartbeatPolicy struct {
+	PingInterval time.Duration //发送ping帧的间隔，默认5秒
+	PongTimeout  time.Duration //超过该时间没有收到客户端任何数据时断开，默认为3倍 PingInterval，小于0时不检测
+	IdleTimeout  time.Duration //超过该时间没有请求action时断开，0不限制
+	WriteTimeout time.Duration //单条消息写入超时时间，默认10秒
+}
+
+// DefaultHeartbeat 默认心跳策略
+var DefaultHeartbeat = HeartbeatPolicy{
+	PingInterval: 5 * time.Second,
+	WriteTimeout: 10 * time.Second,
+}
+
+// 空闲断开原因
+const (
+	IdleReasonPong    = "pong"
+	IdleReasonRequest = "request"
+)
+
+func (p HeartbeatPolicy) withDefaults() HeartbeatPolicy {
+	if p.PingInterval <= 0 {
+		p.PingInterval = DefaultHeartbeat.PingInterval
+	}
+
+	if p.PongTimeout == 0 {
+		p.PongTimeout = 3 * p.PingInterval
+	}
+
+	if p.WriteTimeout <= 0 {
+		p.WriteTimeout = DefaultHeartbeat.WriteTimeout
+	}
+
+	return p
+}
+
+// SetHeartbeat 设置之后建立的websocket连接使用的心跳策略
+func (s *Server) SetHeartbeat(policy HeartbeatPolicy) {
+	s.heartbeatMu.Lock()
+	defer s.heartbeatMu.Unlock()
+
+	s.heartbeat = policy.withDefaults()
+}
+
+func (s *Server) heartbeatPolicy() HeartbeatPolicy {
+	s.heartbeatMu.Lock()
+	defer s.heartbeatMu.Unlock()
+
+	return s.heartbeat.withDefaults()
+}
+
+// touch 收到客户端数据，刷新心跳时间和读取超时
+//
+// 超时由发送协程定时检查，读取超时多留出两个ping间隔，用于处理发送协程阻塞的半开连接
+func (c *Client) touch() {
+	t := c.Hub.now()
+	c.lastSeen.Store(t.UnixNano())
+	if c.heartbeat.PongTimeout > 0 {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.heartbeat.PongTimeout + 2*c.heartbeat.PingInterval))
+	}
+}
+
+// idleReason 检查心跳和请求是否超时，未超时返回空字符串
+func (c *Client) idleReason() string {
+	now := c.Hub.now()
+	if c.heartbeat.PongTimeout > 0 && now.Sub(time.Unix(0, c.lastSeen.Load())) > c.heartbeat.PongTimeout {
+		return IdleReasonPong
+	}
+
+	if c.heartbeat.IdleTimeout > 0 {
+		last := c.lastRequest.Load()
+		if last == 0 {
+			last = c.ConnectionTime.UnixNano()
+		}
+
+		if now.Sub(time.Unix(0, last)) > c.heartbeat.IdleTimeout {
+			return IdleReasonRequest
+		}
+	}
+
+	return ""
+}
+
+// closeIdle 通知客户端因空闲被断开，只发送一次
+func (c *Client) closeIdle(reason string) {
+	if !c.idleClosed.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.Log("xx", "Idle timeout", reason)
+	msg := &Action{
+		Action: "sys.idleTimeout",
+		Code:   -1007,
+		Msg:    "connection idle timeout",
+		Data:   H{"reason": reason},
+	}
+
+	_ = c.writeFrame(ws.OpText, msg.Encode())
+}
+
+// writeFrame 写入消息，与读取协程回复控制帧共用写锁
+func (c *Client) writeFrame(op ws.OpCode, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.heartbeat.WriteTimeout > 0 {
+		_ = c.Conn.SetWriteDeadline(time.Now().Add(c.heartbeat.WriteTimeout))
+	}
+
+	return wsutil.WriteServerMessage(c.Conn, op, data)
+}
+
+// lockedConnWriter 控制帧处理函数使用的写入器
+type lockedConnWriter struct {
+	c *Client
+}
+
+func (w lockedConnWriter) Write(p []byte) (int, error) {
+	w.c.writeMu.Lock()
+	defer w.c.writeMu.Unlock()
+
+	return w.c.Conn.Write(p)
+}
@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func dialHeartbeatServer(t *testing.T, policy HeartbeatPolicy) net.Conn {
+	t.Helper()
+
+	s := NewInstance(http.NewServeMux())
+	t.Cleanup(s.Close)
+	s.SetHeartbeat(policy)
+
+	svr := httptest.NewServer(http.HandlerFunc(s.HttpHandler))
+	t.Cleanup(svr.Close)
+
+	conn, _, _, err := ws.Dial(context.Background(), "ws"+strings.TrimPrefix(svr.URL, "http"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// readIdleTimeout 读取服务端消息直到连接关闭，返回 sys.idleTimeout 的原因
+func readIdleTimeout(t *testing.T, conn net.Conn, replyPing bool) string {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	var reason string
+	for {
+		frame, err := ws.ReadFrame(conn)
+		if err != nil {
+			return reason
+		}
+
+		frame = ws.UnmaskFrameInPlace(frame)
+		switch frame.Header.OpCode {
+		case ws.OpPing:
+			if replyPing {
+				require.NoError(t, wsutil.WriteClientMessage(conn, ws.OpPong, frame.Payload))
+			}
+		case ws.OpText:
+			if gjson.GetBytes(frame.Payload, "action").String() == "sys.idleTimeout" {
+				require.Equal(t, int64(-1007), gjson.GetBytes(frame.Payload, "code").Int())
+				reason = gjson.GetBytes(frame.Payload, "data.reason").String()
+			}
+		case ws.OpClose:
+			return reason
+		}
+	}
+}
+
+func TestHeartbeatPongTimeout(t *testing.T) {
+	conn := dialHeartbeatServer(t, HeartbeatPolicy{
+		PingInterval: 20 * time.Millisecond,
+		PongTimeout:  60 * time.Millisecond,
+	})
+
+	//不回复pong，等待超时后再读取
+	time.Sleep(150 * time.Millisecond)
+	require.Equal(t, IdleReasonPong, readIdleTimeout(t, conn, false))
+}
+
+func TestHeartbeatIdleTimeout(t *testing.T) {
+	conn := dialHeartbeatServer(t, HeartbeatPolicy{
+		PingInterval: 20 * time.Millisecond,
+		PongTimeout:  time.Second,
+		IdleTimeout:  100 * time.Millisecond,
+	})
+
+	start := time.Now()
+	require.Equal(t, IdleReasonRequest, readIdleTimeout(t, conn, true))
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestHeartbeatPolicyDefaults(t *testing.T) {
+	p := HeartbeatPolicy{}.withDefaults()
+	require.Equal(t, 5*time.Second, p.PingInterval)
+	require.Equal(t, 15*time.Second, p.PongTimeout)
+	require.Equal(t, time.Duration(0), p.IdleTimeout)
+
+	p = HeartbeatPolicy{PingInterval: time.Second, PongTimeout: -1}.withDefaults()
+	require.Equal(t, time.Duration(-1), p.PongTimeout)
+}
@@ -0,0 +1,39 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientClose(t *testing.T) {
+	c := &Client{Send: make(chan []byte, 1)}
+	c.SendMsg([]byte("queued"))
+
+	//队列已满时等待入队的发送方在关闭后返回
+	blocked := make(chan struct{})
+	go func() {
+		c.SendMsg([]byte("blocked"))
+		close(blocked)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	c.Close()
+
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("SendMsg still blocked after Close")
+	}
+
+	require.True(t, c.IsClosed())
+	c.SendMsg([]byte("late"))
+
+	//已入队的消息保留到通道关闭
+	msg, ok := <-c.Send
+	require.True(t, ok)
+	require.Equal(t, "queued", string(msg))
+	_, ok = <-c.Send
+	require.False(t, ok)
+}
@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"net/http"
+
+	"golang.org/x/time/rate"
+
+	"github.com/wonli/aqi/utils"
+	"github.com/wonli/aqi/utils/ip"
+)
+
+// 客户端传输方式
+const (
+	TransportWebsocket   = "websocket"
+	TransportSSE         = "sse"
+	TransportLongPolling = "polling"
+)
+
+// newSessionClient 创建SSE或长轮询客户端，与websocket客户端一样加入Hub
+//
+// 这类客户端没有网络连接，消息通过 Send 通道由对应的HTTP请求取走，
+// action 通过 SessionHandler 提交
+func (s *Server) newSessionClient(r *http.Request, transport string, sendSize int, release func()) (*Client, error) {
+	ipAddr := ip.GetIPAddress(r)
+	c := &Client{
+		Hub:            s.hub,
+		Send:           make(chan []byte, sendSize),
+		Transport:      transport,
+		SessionId:      utils.GetRandomString(32),
+		Limiter:        rate.NewLimiter(50, 100),
+		Endpoint:       r.URL.Path,
+		IpAddress:      ipAddr,
+		IpAddressPort:  r.RemoteAddr,
+		ConnectionTime: s.hub.now(),
+		HttpRequest:    r,
+		release:        release,
+	}
+
+	c.lastSeen.Store(c.ConnectionTime.UnixNano())
+	c.Hub.sessions.Store(c.SessionId, c)
+	if err := c.Hub.connect(c); err != nil {
+		c.Hub.sessions.Delete(c.SessionId)
+		release()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// sessionClient 根据会话ID获取SSE或长轮询客户端
+func (s *Server) sessionClient(r *http.Request) *Client {
+	sessionId := r.Header.Get("X-Session-Id")
+	if sessionId == "" {
+		sessionId = r.URL.Query().Get("session")
+	}
+
+	if sessionId == "" {
+		return nil
+	}
+
+	c, ok := s.hub.sessions.Load(sessionId)
+	if !ok {
+		return nil
+	}
+
+	return c.(*Client)
+}
@@ -0,0 +1,8 @@
+package ws
+
+import "time"
+
+// Clock 时间来源，测试时可替换为手动推进的时钟
+type Clock interface {
+	Now() time.Time
+}
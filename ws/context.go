@@ -23,6 +23,8 @@ type Context struct {
 
 	language   string
 	defaultLng string
+
+	httpStatus int
 }
 
 const abortIndex int8 = math.MaxInt8 / 2
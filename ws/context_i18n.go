@@ -1,9 +1,21 @@
 package ws
 
+// Language 当前请求的语言
+func (c *Context) Language() string {
+	return c.language
+}
+
+// SetLanguage 设置当前请求的语言，影响 SendCode 的提示翻译和 i18n.Text 的输出
+func (c *Context) SetLanguage(lng string) {
+	if lng != "" {
+		c.language = lng
+	}
+}
+
 func (c *Context) i18nLoad(code int, msg string) string {
-	return languageInit(c).load(code, msg)
+	return c.Server.language().get(c.language).load(c.Action, code, msg)
 }
 
 func (c *Context) i18nSet(code int, msg string) {
-	languageInit(c).set(code, msg)
+	c.Server.language().get(c.language).set(c.Action, code, msg)
 }
@@ -1,84 +1,124 @@
 package ws
 
 import (
+	"errors"
 	"fmt"
 	"hash/fnv"
-	"os"
 	"sync"
 
-	"gopkg.in/yaml.v3"
-
-	"github.com/wonli/aqi/utils"
-
 	"github.com/wonli/aqi/logger"
 )
 
-var langMap sync.Map
+// langCache 按语言缓存翻译，可在运行时重新加载
+type langCache struct {
+	store LangStore
+
+	mu    sync.RWMutex
+	langs map[string]*langInfo
+}
 
 type langInfo struct {
-	ctx *Context
+	lang  string
+	store LangStore
 
+	mu       sync.RWMutex
 	langData map[string]string
-	filePath string
 }
 
-func languageInit(ctx *Context) *langInfo {
-	lang := ctx.language
-	value, ok := langMap.Load(lang)
-	if ok {
-		return value.(*langInfo)
+func newLangCache(store LangStore) *langCache {
+	return &langCache{
+		store: store,
+		langs: make(map[string]*langInfo),
 	}
+}
 
-	languageFile := fmt.Sprintf("%s/i18n/%s.yaml", ctx.Server.dataPath, lang)
-	err := utils.CreateFileIfNotExists(languageFile)
-	if err != nil {
-		return nil
+// get 获取语言包，首次使用时从存储加载
+func (lc *langCache) get(lang string) *langInfo {
+	lc.mu.RLock()
+	info, ok := lc.langs[lang]
+	lc.mu.RUnlock()
+	if ok {
+		return info
 	}
 
-	file, err := os.ReadFile(languageFile)
-	if err != nil {
-		logger.SugarLog.Errorf("Failed to load language file:%s", err.Error())
-		return nil
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	info, ok = lc.langs[lang]
+	if ok {
+		return info
 	}
 
-	res := &langInfo{
-		ctx:      ctx,
+	info = &langInfo{
+		lang:     lang,
+		store:    lc.store,
 		langData: make(map[string]string),
-		filePath: languageFile,
 	}
 
-	if len(file) == 0 {
-		return res
+	info.reload()
+	lc.langs[lang] = info
+	return info
+}
+
+// reload 重新加载指定语言，不指定时重新加载全部已缓存的语言
+func (lc *langCache) reload(langs ...string) {
+	lc.mu.RLock()
+	if len(langs) == 0 {
+		for lang := range lc.langs {
+			langs = append(langs, lang)
+		}
+	}
+
+	var list []*langInfo
+	for _, lang := range langs {
+		if info, ok := lc.langs[lang]; ok {
+			list = append(list, info)
+		}
+	}
+	lc.mu.RUnlock()
+
+	for _, info := range list {
+		info.reload()
 	}
+}
 
-	err = yaml.Unmarshal(file, &res.langData)
+func (info *langInfo) reload() {
+	data, err := info.store.Load(info.lang)
 	if err != nil {
-		logger.SugarLog.Errorf("Failed to unmarshal language file:%s", err.Error())
-		return nil
+		logger.SugarLog.Errorf("Failed to load language %s: %s", info.lang, err.Error())
+		return
 	}
 
-	langMap.Store(lang, res)
-	return res
+	info.mu.Lock()
+	info.langData = data
+	info.mu.Unlock()
 }
 
-func (info *langInfo) set(code int, msg string) {
-	mHash := info.getMsgHashKey(msg)
-	cacheKey := fmt.Sprintf("%s.%d.%s", info.ctx.Action, code, mHash)
+func (info *langInfo) set(action string, code int, msg string) {
+	cacheKey := langKey(action, code, msg)
+
+	info.mu.Lock()
 	_, ok := info.langData[cacheKey]
 	if !ok {
 		info.langData[cacheKey] = msg
+	}
+	info.mu.Unlock()
 
-		// 将更新后的语言包数据写入文件
-		if err := writeLangFile(info.filePath, info.langData); err != nil {
-			logger.SugarLog.Errorf("Failed to update language file: %s", err.Error())
-		}
+	if ok {
+		return
+	}
+
+	err := info.store.Set(info.lang, cacheKey, msg)
+	if err != nil && !errors.Is(err, ErrLangStoreReadOnly) {
+		logger.SugarLog.Errorf("Failed to update language store: %s", err.Error())
 	}
 }
 
-func (info *langInfo) load(code int, msg string) string {
-	mHash := info.getMsgHashKey(msg)
-	cacheKey := fmt.Sprintf("%s.%d.%s", info.ctx.Action, code, mHash)
-	s, ok := info.langData[cacheKey]
+func (info *langInfo) load(action string, code int, msg string) string {
+	info.mu.RLock()
+	defer info.mu.RUnlock()
+
+	s, ok := info.langData[langKey(action, code, msg)]
 	if ok {
 		return s
 	}
@@ -86,7 +126,11 @@ func (info *langInfo) load(code int, msg string) string {
 	return msg
 }
 
-func (info *langInfo) getMsgHashKey(msg string) string {
+func langKey(action string, code int, msg string) string {
+	return fmt.Sprintf("%s.%d.%s", action, code, getMsgHashKey(msg))
+}
+
+func getMsgHashKey(msg string) string {
 	h := fnv.New32a()
 	_, err := h.Write([]byte(msg))
 	if err != nil {
@@ -96,14 +140,3 @@ func (info *langInfo) getMsgHashKey(msg string) string {
 
 	return fmt.Sprintf("%04d", h.Sum32()%10000)
 }
-
-func writeLangFile(filePath string, data map[string]string) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	encoder := yaml.NewEncoder(file)
-	return encoder.Encode(data)
-}
@@ -0,0 +1,24 @@
+package ws
+
+import "errors"
+
+var ErrLangStoreReadOnly = errors.New("language store is read-only")
+
+// LangStore 语言包存储
+//
+// key 格式为 action.code.hash，与 SendCode 翻译时使用的键一致
+type LangStore interface {
+	// Load 读取指定语言的全部翻译
+	Load(lang string) (map[string]string, error)
+
+	// Set 写入一条翻译，已存在的键保持不变
+	Set(lang, key, msg string) error
+}
+
+// LangWatcher 支持变更通知的语言包存储
+//
+// 存储内容变化时回调 fn，服务端据此重新加载对应语言
+type LangWatcher interface {
+	Watch(fn func(lang string)) error
+	Close() error
+}
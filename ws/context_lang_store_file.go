@@ -0,0 +1,173 @@
+package ws
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+
+	"github.com/wonli/aqi/logger"
+)
+
+// FileLangStore 以 {dir}/{lang}.yaml 保存语言包
+type FileLangStore struct {
+	dir string
+
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+}
+
+func NewFileLangStore(dir string) *FileLangStore {
+	return &FileLangStore{dir: dir}
+}
+
+func (s *FileLangStore) Load(lang string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(lang)
+}
+
+func (s *FileLangStore) Set(lang, key, msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.read(lang)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := data[key]; ok {
+		return nil
+	}
+
+	data[key] = msg
+	return writeLangFile(s.filePath(lang), data)
+}
+
+// Watch 监听语言包目录，文件被修改后回调对应语言
+func (s *FileLangStore) Watch(fn func(lang string)) error {
+	err := os.MkdirAll(s.dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	err = watcher.Add(s.dir)
+	if err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	if s.watcher != nil {
+		_ = s.watcher.Close()
+	}
+	s.watcher = watcher
+	s.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if !e.Has(fsnotify.Write) && !e.Has(fsnotify.Create) && !e.Has(fsnotify.Rename) {
+					continue
+				}
+
+				name := filepath.Base(e.Name)
+				if filepath.Ext(name) != ".yaml" {
+					continue
+				}
+
+				fn(strings.TrimSuffix(name, ".yaml"))
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				logger.SugarLog.Errorf("Language file watcher error: %s", err.Error())
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *FileLangStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.watcher == nil {
+		return nil
+	}
+
+	err := s.watcher.Close()
+	s.watcher = nil
+	return err
+}
+
+func (s *FileLangStore) read(lang string) (map[string]string, error) {
+	data := make(map[string]string)
+	file, err := os.ReadFile(s.filePath(lang))
+	if errors.Is(err, fs.ErrNotExist) {
+		return data, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(file) == 0 {
+		return data, nil
+	}
+
+	err = yaml.Unmarshal(file, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *FileLangStore) filePath(lang string) string {
+	return filepath.Join(s.dir, lang+".yaml")
+}
+
+// writeLangFile 先写临时文件再替换，避免监听方读到写了一半的文件
+func writeLangFile(filePath string, data map[string]string) error {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	tmpFile := filePath + ".tmp"
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+
+	err = yaml.NewEncoder(file).Encode(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return err
+	}
+
+	return os.Rename(tmpFile, filePath)
+}
@@ -0,0 +1,48 @@
+package ws
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FSLangStore 从 fs.FS（如 embed.FS）读取 {dir}/{lang}.yaml
+//
+// 内嵌文件只读，开发模式下新增的提示只保存在内存中
+type FSLangStore struct {
+	fsys fs.FS
+	dir  string
+}
+
+func NewFSLangStore(fsys fs.FS, dir string) *FSLangStore {
+	return &FSLangStore{fsys: fsys, dir: dir}
+}
+
+func (s *FSLangStore) Load(lang string) (map[string]string, error) {
+	data := make(map[string]string)
+	file, err := fs.ReadFile(s.fsys, path.Join(s.dir, lang+".yaml"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return data, nil
+		}
+
+		return nil, err
+	}
+
+	if len(file) == 0 {
+		return data, nil
+	}
+
+	err = yaml.Unmarshal(file, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *FSLangStore) Set(lang, key, msg string) error {
+	return ErrLangStoreReadOnly
+}
@@ -0,0 +1,58 @@
+package ws
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LangMessage 语言包数据表
+type LangMessage struct {
+	Id          uint      `gorm:"primaryKey" json:"id"`
+	Lang        string    `gorm:"size:32;uniqueIndex:idx_lang_key" json:"lang"`
+	Key         string    `gorm:"size:191;uniqueIndex:idx_lang_key" json:"key"`
+	Msg         string    `gorm:"type:text" json:"msg"`
+	CreatedTime time.Time `gorm:"autoCreateTime" json:"createdTime"`
+	UpdatedTime time.Time `gorm:"autoUpdateTime" json:"updatedTime"`
+}
+
+// SQLLangStore 将语言包保存到数据库
+//
+// 数据库中的翻译被修改后调用 Server.ReloadLanguage 生效
+type SQLLangStore struct {
+	db *gorm.DB
+}
+
+// NewSQLLangStore db 一般来自 store.DB(key).Use()
+func NewSQLLangStore(db *gorm.DB) *SQLLangStore {
+	return &SQLLangStore{db: db}
+}
+
+// AutoMigrate 创建语言包数据表
+func (s *SQLLangStore) AutoMigrate() error {
+	return s.db.AutoMigrate(&LangMessage{})
+}
+
+func (s *SQLLangStore) Load(lang string) (map[string]string, error) {
+	var rows []LangMessage
+	err := s.db.Where("lang = ?", lang).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string, len(rows))
+	for _, row := range rows {
+		data[row.Key] = row.Msg
+	}
+
+	return data, nil
+}
+
+func (s *SQLLangStore) Set(lang, key, msg string) error {
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&LangMessage{
+		Lang: lang,
+		Key:  key,
+		Msg:  msg,
+	}).Error
+}
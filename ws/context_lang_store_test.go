@@ -0,0 +1,95 @@
+package ws
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestFileLangStoreHotReload(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFileLangStore(dir)
+
+	s := &Server{}
+	s.SetLangStore(store)
+	defer s.SetLangStore(nil)
+
+	info := s.language().get("en")
+	require.Equal(t, "用户注册失败", info.load("user.register", 1001, "用户注册失败"))
+
+	key := langKey("user.register", 1001, "用户注册失败")
+	err := os.WriteFile(filepath.Join(dir, "en.yaml"), []byte(key+": Registration failed\n"), 0644)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return info.load("user.register", 1001, "用户注册失败") == "Registration failed"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestFileLangStoreConcurrentSet(t *testing.T) {
+	s := &Server{}
+	s.SetLangStore(NewFileLangStore(t.TempDir()))
+	defer s.SetLangStore(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			info := s.language().get("zh")
+			msg := fmt.Sprintf("msg-%d", i)
+			info.set("concurrent", i, msg)
+			_ = info.load("concurrent", i, msg)
+		}(i)
+	}
+
+	wg.Wait()
+
+	data, err := s.langStore.Load("zh")
+	require.NoError(t, err)
+	require.Len(t, data, 20)
+}
+
+func TestSQLLangStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	store := NewSQLLangStore(db)
+	require.NoError(t, store.AutoMigrate())
+
+	require.NoError(t, store.Set("en", "a.1.0001", "first"))
+	require.NoError(t, store.Set("en", "a.1.0001", "second"))
+
+	s := &Server{}
+	s.SetLangStore(store)
+	require.Equal(t, "first", s.language().get("en").langData["a.1.0001"])
+
+	require.NoError(t, db.Model(&LangMessage{}).Where("lang = ? AND `key` = ?", "en", "a.1.0001").Update("msg", "updated").Error)
+	s.ReloadLanguage("en")
+	require.Equal(t, "updated", s.language().get("en").langData["a.1.0001"])
+}
+
+func TestFSLangStore(t *testing.T) {
+	key := langKey("hi", 1, "你好")
+	fsys := fstest.MapFS{
+		"i18n/en.yaml": &fstest.MapFile{Data: []byte(key + ": hello\n")},
+	}
+
+	s := &Server{}
+	s.SetLangStore(NewFSLangStore(fsys, "i18n"))
+
+	require.Equal(t, "hello", s.language().get("en").load("hi", 1, "你好"))
+	require.Equal(t, "你好", s.language().get("ja").load("hi", 1, "你好"))
+
+	s.language().get("en").set("hi", 2, "新提示")
+	require.Equal(t, "新提示", s.language().get("en").load("hi", 2, "新提示"))
+}
@@ -1,8 +1,8 @@
 package ws
 
-// Pub 发布消息到主题
+// Pub 发布消息到当前租户的主题
 func (c *Context) Pub(topicId string, data any) {
-	c.Client.Hub.PubSub.Pub(topicId, data)
+	c.Client.Hub.PubSub.Tenant(c.Client.TenantId).Pub(topicId, data)
 }
 
 // Sub 订阅主题（当前用户）
@@ -12,9 +12,9 @@ func (c *Context) Sub(topicId string) {
 	}
 }
 
-// SubFunc 以函数方式订阅主题
+// SubFunc 以函数方式订阅当前租户的主题
 func (c *Context) SubFunc(topicId string, f func(msg *TopicMsg)) {
-	c.Client.Hub.PubSub.SubFunc(topicId, f)
+	c.Client.Hub.PubSub.Tenant(c.Client.TenantId).SubFunc(topicId, f)
 }
 
 // Unsub 取消订阅主题（当前用户）
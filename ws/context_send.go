@@ -1,8 +1,13 @@
 package ws
 
-// Send 发送数据给用户
+import (
+	"github.com/wonli/aqi/utils/geo"
+	"github.com/wonli/aqi/utils/i18n"
+)
+
+// Send 发送数据给用户，数据中的 i18n.Text 按当前语言输出
 func (c *Context) Send(data any) {
-	msg := New(c.Action).WithId(c.Id).WithData(data)
+	msg := New(c.Action).WithId(c.Id).WithData(i18n.Localize(data, c.language))
 
 	c.Response = msg
 	c.Client.SendMsg(msg.Encode())
@@ -36,6 +41,12 @@ func (c *Context) SendCode(code int, msg string) {
 	c.Client.SendMsg(m.Encode())
 }
 
+// SendError 发送错误，通过 ApiHandler 调用时使用 Error.HttpStatus 作为HTTP状态码
+func (c *Context) SendError(e *Error) {
+	c.httpStatus = e.HttpStatus
+	c.SendCode(e.Code, e.Msg)
+}
+
 // SendMsg 发送消息给当前用户
 func (c *Context) SendMsg(msg string) {
 	m := New(c.Action).WithId(c.Id).WithMsg(msg)
@@ -52,7 +63,7 @@ func (c *Context) SendAction(m *Action) {
 
 // SendActionData 发送数据给当前用户
 func (c *Context) SendActionData(action string, data any) {
-	m := New(action).WithData(data)
+	m := New(action).WithData(i18n.Localize(data, c.language))
 
 	c.Response = m
 	c.Client.SendMsg(m.Encode())
@@ -66,12 +77,12 @@ func (c *Context) SendActionMsg(action, msg string) {
 	c.Client.SendMsg(m.Encode())
 }
 
-// SendTo 发送给指定用户
+// SendTo 发送给当前租户内的指定用户
 func (c *Context) SendTo(uid, action string, data any) {
 	m := New(action).WithData(data)
 
 	c.Response = m
-	user := c.Client.Hub.User(uid)
+	user := c.Client.Hub.TenantUser(c.Client.TenantId, uid)
 	if user != nil {
 		user.SendMsg(m.Encode())
 	}
@@ -95,17 +106,23 @@ func (c *Context) SendToApps(msg *Action) {
 	}
 }
 
-// SendRawTo 发送RAW消息给指定用户
+// SendRawTo 发送RAW消息给当前租户内的指定用户
 func (c *Context) SendRawTo(uid string, msg *Action) {
 	c.Response = msg
-	user := c.Client.Hub.User(uid)
+	user := c.Client.Hub.TenantUser(c.Client.TenantId, uid)
 	if user != nil {
 		user.SendMsg(msg.Encode())
 	}
 }
 
-// Broadcast 发送广播
+// Broadcast 向当前租户发送广播
 func (c *Context) Broadcast(msg *Action) {
 	c.Response = msg
-	c.Client.Hub.Broadcast(msg.Encode())
+	c.Client.Hub.BroadcastTenant(c.Client.TenantId, msg.Encode())
+}
+
+// BroadcastNear 向当前租户 radius 范围内的在线用户发送广播，返回用户数
+func (c *Context) BroadcastNear(coord geo.Coordinate, radius geo.Distance, msg *Action) int {
+	c.Response = msg
+	return c.Client.Hub.BroadcastTenantNear(c.Client.TenantId, coord, radius, msg.Encode())
 }
@@ -0,0 +1,66 @@
+package ws
+
+import (
+	"net/http"
+	"sync"
+)
+
+var (
+	wss  *Server
+	once sync.Once
+)
+
+// Default 默认 Server，包级别的函数都作用于该实例
+func Default() *Server {
+	once.Do(func() {
+		wss = NewInstance(nil)
+		Hub = wss.hub
+	})
+
+	return wss
+}
+
+// NewServer 返回默认 Server，首次调用时设置 engine
+func NewServer(engine http.Handler) *Server {
+	s := Default()
+	if s.engine == nil {
+		s.engine = engine
+	}
+
+	return s
+}
+
+// InitManager 默认 Server 的路由表
+func InitManager() *ActionManager {
+	return Default().manager
+}
+
+// HttpHandler 使用默认 Server 处理websocket连接
+func HttpHandler(w http.ResponseWriter, r *http.Request) {
+	Default().HttpHandler(w, r)
+}
+
+// ApiHandler 使用默认 Server 以HTTP方式调用action
+func ApiHandler(w http.ResponseWriter, r *http.Request) {
+	Default().ApiHandler(w, r)
+}
+
+// SSEHandler 使用默认 Server 处理SSE连接
+func SSEHandler(w http.ResponseWriter, r *http.Request) {
+	Default().SSEHandler(w, r)
+}
+
+// PollHandler 使用默认 Server 处理长轮询
+func PollHandler(w http.ResponseWriter, r *http.Request) {
+	Default().PollHandler(w, r)
+}
+
+// SessionHandler 使用默认 Server 接收SSE和长轮询客户端提交的action
+func SessionHandler(w http.ResponseWriter, r *http.Request) {
+	Default().SessionHandler(w, r)
+}
+
+// AdminHandler 默认 Server 的管理接口
+func AdminHandler(token string) http.Handler {
+	return Default().AdminHandler(token)
+}
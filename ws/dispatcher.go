@@ -2,42 +2,54 @@ package ws
 
 import (
 	"context"
-	"time"
 
 	"github.com/tidwall/gjson"
+
+	"github.com/wonli/aqi/store"
 )
 
-func Dispatcher(c *Client, request string) {
-	var req struct {
-		Id     string `json:"id"`
-		Action string `json:"action"`
-		Params string `json:"params"`
-	}
+type request struct {
+	Id     string `json:"id"`
+	Action string `json:"action"`
+	Params string `json:"params"`
+}
 
-	result := gjson.Parse(request)
-	req.Id = result.Get("id").String()
-	req.Params = result.Get("params").String()
-	req.Action = result.Get("action").String()
+func Dispatcher(c *Client, req string) {
+	result := gjson.Parse(req)
+	dispatch(c, request{
+		Id:     result.Get("id").String(),
+		Params: result.Get("params").String(),
+		Action: result.Get("action").String(),
+	})
+}
 
+// dispatch 执行请求，返回处理请求的 Context，请求未进入路由时返回 nil
+func dispatch(c *Client, req request) *Context {
 	//ping直接回应
-	t := time.Now()
+	t := c.Hub.now()
 	if req.Action == "ping" {
 		c.LastHeartbeatTime = t
 		c.SendActionMsg(&Action{Action: "ping", Msg: "pong"})
-		return
+		return nil
 	}
 
 	//是否被禁言
 	if c.User != nil {
-		isBanned, bandTime := c.User.IsBanned()
-		if isBanned {
-			c.SendActionMsg(&Action{Action: "sys.ban", Code: -1001, Data: bandTime})
-			return
+		ban := c.User.ActiveBan()
+		if ban != nil && ban.Match(req.Action) {
+			c.SendMsg(banAction(ban))
+			return nil
 		}
 	}
 
 	//更新最后请求时间
 	c.LastRequestTime = t
+	c.lastRequest.Store(t.UnixNano())
+
+	s := c.server()
+	if c.User != nil {
+		s.touchPresence(c.User)
+	}
 
 	//如果心跳时间为0，设置为当前时间
 	//防止在连接瞬间被哨兵扫描而断开
@@ -45,10 +57,10 @@ func Dispatcher(c *Client, request string) {
 		c.LastHeartbeatTime = t
 	}
 
-	handlers := InitManager().Handlers(req.Action)
+	handlers := s.manager.Handlers(req.Action)
 	if len(handlers) == 0 {
 		c.SendActionMsg(&Action{Action: req.Action, Code: -1005, Msg: "request not supported"})
-		return
+		return nil
 	}
 
 	ctx := &Context{
@@ -57,7 +69,7 @@ func Dispatcher(c *Client, request string) {
 		Action: req.Action,
 
 		Client: c,
-		Server: wss,
+		Server: s,
 
 		handlers: handlers,
 		ctx:      context.Background(),
@@ -70,8 +82,14 @@ func Dispatcher(c *Client, request string) {
 		ctx.ctx = c.HttpRequest.Context()
 	}
 
+	//租户ID写入context，数据库查询可通过 store.RegisterTenant 自动按租户过滤
+	if c.TenantId != 0 {
+		ctx.ctx = store.WithTenant(ctx.ctx, c.TenantId)
+	}
+
 	defer ctx.FlushLog()
 
 	ctx.handlers[0](ctx)
 	ctx.Next()
+	return ctx
 }
@@ -1,17 +1,23 @@
 package ws
 
 import (
+	"errors"
 	"sync"
 	"time"
 
 	"golang.org/x/exp/slices"
 )
 
+// Hub 默认 Server 的 Hubc，使用独立 Server 时通过 Server.Hub 获取
 var Hub *Hubc
 
+// ErrHubStopped Hub 已停止，不再接收新连接
+var ErrHubStopped = errors.New("hub stopped")
+
 type Hubc struct {
 	//访客列表
-	Guests []*Client
+	Guests   []*Client
+	guestsMu sync.RWMutex
 
 	//已登录用户 map[string]*User
 	Users *sync.Map
@@ -26,28 +32,52 @@ type Hubc struct {
 	//登录和断开通道
 	Connection chan *Client
 	Disconnect chan *Client
+
+	//时间来源，为空时使用系统时间
+	Clock Clock
+
+	//SSE和长轮询客户端 map[string]*Client
+	sessions sync.Map
+
+	//在线用户位置索引
+	geo *geoIndex
+
+	server   *Server
+	guardFn  GuardFunc
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
 type GuardFunc func(h *Hubc)
 
-// GuardFunc 守护回调
-var guardFn GuardFunc
-
-// SetGuardFunc 设置全局的 Hubc 守护回调
+// SetGuardFunc 设置默认 Server 的 Hubc 守护回调
 func SetGuardFunc(fn GuardFunc) {
-	guardFn = fn
+	Default().hub.SetGuardFunc(fn)
 }
 
+// NewHubc 创建独立的 Hubc，需要调用方执行 Run
+//
+// 不再替换默认 Server 和全局 Hub 使用的 Hubc，与 NewHub 相同
+//
+// Deprecated: 使用 NewInstance 创建独立的 Server，或使用 NewHub
 func NewHubc() *Hubc {
-	Hub = &Hubc{
+	return NewHub()
+}
+
+// NewHub 创建独立的 Hubc，不替换全局 Hub
+func NewHub() *Hubc {
+	h := &Hubc{
 		PubSub:     NewPubSub(),
 		Guests:     []*Client{},
 		Users:      new(sync.Map),
 		Connection: make(chan *Client),
 		Disconnect: make(chan *Client),
+		done:       make(chan struct{}),
+		geo:        newGeoIndex(),
 	}
 
-	return Hub
+	h.PubSub.hub = h
+	return h
 }
 
 func (h *Hubc) Run() {
@@ -57,11 +87,21 @@ func (h *Hubc) Run() {
 	for {
 		select {
 		case c := <-h.Connection:
+			h.guestsMu.Lock()
 			h.Guests = append(h.Guests, c)
+			h.guestsMu.Unlock()
 			h.PubSub.Pub("connect", c)
 			c.Log("--", "connection")
 
 		case c := <-h.Disconnect:
+			if c.release != nil {
+				c.release()
+			}
+
+			if c.SessionId != "" {
+				h.sessions.Delete(c.SessionId)
+			}
+
 			h.PubSub.Pub("disconnect", c)
 			if c.User != nil {
 				err := c.User.appLogout(c.AppId, c)
@@ -72,52 +112,107 @@ func (h *Hubc) Run() {
 				c.Close()
 				h.removeFromGuests(c)
 			}
+
+		case <-h.done:
+			return
 		}
 	}
 }
 
+// connect 把客户端交给 Run 注册，Hub 停止后返回 ErrHubStopped
+func (h *Hubc) connect(c *Client) error {
+	select {
+	case h.Connection <- c:
+		return nil
+	case <-h.done:
+		return ErrHubStopped
+	}
+}
+
+// disconnect 把客户端交给 Run 断开，Hub 停止后直接返回
+func (h *Hubc) disconnect(c *Client) {
+	select {
+	case h.Disconnect <- c:
+	case <-h.done:
+	}
+}
+
+// SetGuardFunc 设置守护回调，每次守护检查时执行
+func (h *Hubc) SetGuardFunc(fn GuardFunc) {
+	h.guardFn = fn
+}
+
+// Stop 停止 Run 启动的协程
+func (h *Hubc) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.done)
+		h.PubSub.Stop()
+	})
+}
+
 func (h *Hubc) guard() {
-	cleanupTTL := 5 * time.Minute
 	timer := time.NewTicker(30 * time.Second)
-	for range timer.C {
-		if guardFn != nil {
-			guardFn(h)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			h.Sweep()
+		case <-h.done:
+			return
 		}
+	}
+}
 
-		userCount := 0
-		guestCount := len(h.Guests)
-		h.Users.Range(func(key, value any) bool {
-			user, ok := value.(*User)
-			if !ok || user == nil {
-				return true
+// Sweep 执行一次守护检查，清理离线用户和过期会话并更新用户数统计
+func (h *Hubc) Sweep() {
+	cleanupTTL := 5 * time.Minute
+
+	if h.guardFn != nil {
+		h.guardFn(h)
+	}
+
+	h.reapSessions()
+
+	userCount := 0
+	guestCount := len(h.guests())
+	h.Users.Range(func(key, value any) bool {
+		user, ok := value.(*User)
+		if !ok || user == nil {
+			return true
+		}
+
+		if len(user.Clients()) == 0 {
+			if h.now().Sub(user.LastHeartbeatTime) >= cleanupTTL {
+				user.UnsubAllTopics()
+				h.geo.remove(user)
+				h.Users.Delete(key)
+				h.PubSub.Pub("cleanupUser", H{"suid": user.Suid})
 			}
+		} else {
+			userCount++
 
-			if len(user.AppClients) == 0 {
-				if time.Since(user.LastHeartbeatTime) >= cleanupTTL {
-					user.UnsubAllTopics()
-					h.Users.Delete(key)
-					h.PubSub.Pub("cleanupUser", H{"suid": user.Suid})
-				}
-			} else {
-				userCount++
+			//长时间没有请求自动设为离开
+			if h.server != nil {
+				h.server.sweepPresence(user)
 			}
+		}
 
-			return true
-		})
+		return true
+	})
 
-		//登录用户数
-		h.LoginCount = userCount
-		h.GuestCount = guestCount
+	//登录用户数
+	h.LoginCount = userCount
+	h.GuestCount = guestCount
 
-		//发布订阅消息
-		h.PubSub.Pub("userCount", userCount)
-		h.PubSub.Pub("guestsCount", guestCount)
-	}
+	//发布订阅消息
+	h.PubSub.Pub("userCount", userCount)
+	h.PubSub.Pub("guestsCount", guestCount)
 }
 
-// Broadcast 发送广播消息
+// Broadcast 向全部租户发送广播消息
 func (h *Hubc) Broadcast(msg []byte) {
-	for _, g := range h.Guests {
+	for _, g := range h.guests() {
 		g.SendMsg(msg)
 	}
 
@@ -133,14 +228,9 @@ func (h *Hubc) Broadcast(msg []byte) {
 	}
 }
 
-// User 获取用户信息
+// User 获取用户信息，租户内的用户使用 TenantUser
 func (h *Hubc) User(uid string) *User {
-	user, ok := h.Users.Load(uid)
-	if ok {
-		return user.(*User)
-	}
-
-	return nil
+	return h.TenantUser(0, uid)
 }
 
 // UserClient 获取用户客户端信息
@@ -153,29 +243,80 @@ func (h *Hubc) UserClient(uid, appId string) *Client {
 	return nil
 }
 
-// UserLogin 用户登录
+// UserLogin 用户登录，用户属于 client.TenantId 所在的租户
 func (h *Hubc) UserLogin(uid, appId string, client *Client) error {
-	user := h.User(uid)
-	if user == nil {
+	key := scopedKey(client.TenantId, uid)
+	user := h.TenantUser(client.TenantId, uid)
+	created := user == nil
+	if created {
+		//同一用户并发登录时共用一个 User
 		user = NewUser(uid)
+		user.Hub = h
+		user.TenantId = client.TenantId
+
+		v, loaded := h.Users.LoadOrStore(key, user)
+		user, created = v.(*User), !loaded
+	}
+
+	//恢复封禁状态
+	if h.server != nil {
+		h.server.loadBan(user)
 	}
 
 	//app登录
 	err := user.appLogin(appId, client)
 	if err != nil {
+		if created && len(user.Clients()) == 0 {
+			h.Users.CompareAndDelete(key, user)
+		}
+
 		return err
 	}
 
 	//保存用户
-	h.Users.Store(uid, user)
+	h.Users.Store(key, user)
 	h.removeFromGuests(client)
 	return nil
 }
 
 // 从访客列表中删除
 func (h *Hubc) removeFromGuests(client *Client) {
+	h.guestsMu.Lock()
+	defer h.guestsMu.Unlock()
+
 	index := slices.Index(h.Guests, client)
 	if index > -1 {
 		h.Guests = slices.Delete(h.Guests, index, index+1)
 	}
 }
+
+func (h *Hubc) now() time.Time {
+	if h != nil && h.Clock != nil {
+		return h.Clock.Now()
+	}
+
+	return time.Now()
+}
+
+// guests 访客列表快照
+func (h *Hubc) guests() []*Client {
+	h.guestsMu.RLock()
+	defer h.guestsMu.RUnlock()
+
+	return slices.Clone(h.Guests)
+}
+
+// clients 全部访客和已登录客户端
+func (h *Hubc) clients() []*Client {
+	list := h.guests()
+	h.Users.Range(func(key, value any) bool {
+		user, ok := value.(*User)
+		if ok && user != nil {
+			list = append(list, user.Clients()...)
+		}
+
+		return true
+	})
+
+	return list
+}
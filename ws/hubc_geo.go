@@ -0,0 +1,190 @@
+package ws
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/wonli/aqi/utils/geo"
+)
+
+// geoCellSize 网格边长（度），约5.5公里
+const geoCellSize = 0.05
+
+// 每度纬度对应的距离
+const geoDegree = 111320 * geo.Meter
+
+type geoCell struct {
+	x, y int
+}
+
+// geoIndex 在线用户位置的网格索引
+type geoIndex struct {
+	mu    sync.RWMutex
+	cells map[geoCell]map[*User]geo.Coordinate
+	users map[*User]geoCell
+}
+
+func newGeoIndex() *geoIndex {
+	return &geoIndex{
+		cells: map[geoCell]map[*User]geo.Coordinate{},
+		users: map[*User]geoCell{},
+	}
+}
+
+func geoCellOf(c geo.Coordinate) geoCell {
+	return geoCell{
+		x: int(math.Floor((c.Lng + 180) / geoCellSize)),
+		y: int(math.Floor((c.Lat + 90) / geoCellSize)),
+	}
+}
+
+func (g *geoIndex) update(user *User, coord geo.Coordinate) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.removeLocked(user)
+
+	cell := geoCellOf(coord)
+	if g.cells[cell] == nil {
+		g.cells[cell] = map[*User]geo.Coordinate{}
+	}
+
+	g.cells[cell][user] = coord
+	g.users[user] = cell
+}
+
+func (g *geoIndex) remove(user *User) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.removeLocked(user)
+}
+
+func (g *geoIndex) removeLocked(user *User) {
+	cell, ok := g.users[user]
+	if !ok {
+		return
+	}
+
+	delete(g.users, user)
+	delete(g.cells[cell], user)
+	if len(g.cells[cell]) == 0 {
+		delete(g.cells, cell)
+	}
+}
+
+// near 查询 radius 范围内的用户，按距离由近到远排序
+func (g *geoIndex) near(center geo.Coordinate, radius geo.Distance, match func(*User) bool) []*User {
+	type result struct {
+		user     *User
+		distance geo.Distance
+	}
+
+	var list []result
+	add := func(cell map[*User]geo.Coordinate) {
+		for user, coord := range cell {
+			d := geo.DistanceBetween(center, coord)
+			if d <= radius && match(user) {
+				list = append(list, result{user: user, distance: d})
+			}
+		}
+	}
+
+	g.mu.RLock()
+	latSpan := float64(radius / geoDegree)
+	lngSpan := 360.0
+	if cos := math.Cos(center.Lat * geo.PiOver180); cos > 0.01 {
+		lngSpan = min(latSpan/cos, 360)
+	}
+
+	lo := geoCellOf(geo.Coordinate{Lat: center.Lat - latSpan, Lng: center.Lng - lngSpan})
+	hi := geoCellOf(geo.Coordinate{Lat: center.Lat + latSpan, Lng: center.Lng + lngSpan})
+	columns := int(360 / geoCellSize)
+	width := min(hi.x-lo.x+1, columns)
+	if width*(hi.y-lo.y+1) > len(g.cells) {
+		//范围内的网格比已有网格多时直接遍历
+		for _, cell := range g.cells {
+			add(cell)
+		}
+	} else {
+		for y := lo.y; y <= hi.y; y++ {
+			for i := 0; i < width; i++ {
+				//经度跨越±180度时回绕
+				x := ((lo.x+i)%columns + columns) % columns
+				add(g.cells[geoCell{x: x, y: y}])
+			}
+		}
+	}
+	g.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].distance < list[j].distance
+	})
+
+	users := make([]*User, len(list))
+	for i, r := range list {
+		users[i] = r.user
+	}
+
+	return users
+}
+
+// SetLocation 更新用户位置，在线用户可以通过 Hubc.UsersNear 查询
+func (u *User) SetLocation(loc *Location) {
+	u.Lock()
+	if loc != nil && loc.LatestUpdateAt.IsZero() {
+		loc.LatestUpdateAt = u.Hub.now()
+	}
+
+	u.Location = loc
+	u.Unlock()
+
+	u.indexLocation()
+}
+
+// indexLocation 根据在线状态和位置更新网格索引
+func (u *User) indexLocation() {
+	if u.Hub == nil || u.Hub.geo == nil {
+		return
+	}
+
+	u.RLock()
+	loc := u.Location
+	online := len(u.AppClients) > 0
+	u.RUnlock()
+
+	if loc == nil || !online {
+		u.Hub.geo.remove(u)
+		return
+	}
+
+	u.Hub.geo.update(u, geo.Coordinate{Lat: loc.Latitude, Lng: loc.Longitude})
+}
+
+// UsersNear 查询 radius 范围内已上报位置的在线用户，按距离由近到远排序
+func (h *Hubc) UsersNear(coord geo.Coordinate, radius geo.Distance) []*User {
+	return h.TenantUsersNear(0, coord, radius)
+}
+
+// TenantUsersNear 查询租户内 radius 范围内的在线用户
+func (h *Hubc) TenantUsersNear(tenantId uint, coord geo.Coordinate, radius geo.Distance) []*User {
+	return h.geo.near(coord, radius, func(user *User) bool {
+		return user.TenantId == tenantId && user.IsOnline()
+	})
+}
+
+// BroadcastNear 向 radius 范围内的在线用户发送消息，返回用户数
+func (h *Hubc) BroadcastNear(coord geo.Coordinate, radius geo.Distance, msg []byte) int {
+	return h.BroadcastTenantNear(0, coord, radius, msg)
+}
+
+// BroadcastTenantNear 向租户内 radius 范围内的在线用户发送消息，返回用户数
+func (h *Hubc) BroadcastTenantNear(tenantId uint, coord geo.Coordinate, radius geo.Distance, msg []byte) int {
+	users := h.TenantUsersNear(tenantId, coord, radius)
+	for _, user := range users {
+		user.SendMsg(msg)
+	}
+
+	return len(users)
+}
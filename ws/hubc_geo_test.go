@@ -0,0 +1,53 @@
+package ws
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/wonli/aqi/utils/geo"
+)
+
+func TestUsersNear(t *testing.T) {
+	s := NewInstance(http.NewServeMux())
+	defer s.Close()
+
+	login := func(uid string, tenantId uint, lat, lng float64) *Client {
+		c := &Client{Hub: s.Hub(), Send: make(chan []byte, 8), TenantId: tenantId}
+		require.NoError(t, s.Hub().UserLogin(uid, "app", c))
+		c.User.SetLocation(&Location{Latitude: lat, Longitude: lng})
+		return c
+	}
+
+	center := geo.Coordinate{Lat: 31.2304, Lng: 121.4737}
+	near := login("near", 0, 31.2404, 121.4737) //约1.1公里
+	far := login("far", 0, 31.3304, 121.4737)   //约11公里
+	login("other", 1, 31.2304, 121.4737)
+	login("dateline", 0, 0, 179.999)
+
+	users := s.Hub().UsersNear(center, 20*geo.Kilometer)
+	require.Len(t, users, 2)
+	require.Equal(t, "near", users[0].Suid)
+	require.Equal(t, "far", users[1].Suid)
+
+	require.Len(t, s.Hub().UsersNear(center, 5*geo.Kilometer), 1)
+	require.Len(t, s.Hub().TenantUsersNear(1, center, geo.Kilometer), 1)
+
+	//经度±180度回绕
+	users = s.Hub().UsersNear(geo.Coordinate{Lat: 0, Lng: -179.999}, geo.Kilometer)
+	require.Len(t, users, 1)
+	require.Equal(t, "dateline", users[0].Suid)
+
+	//位置变化和下线后更新索引
+	far.User.SetLocation(&Location{Latitude: 40, Longitude: 116})
+	require.Len(t, s.Hub().UsersNear(center, 20*geo.Kilometer), 1)
+
+	n := s.Hub().BroadcastNear(center, 5*geo.Kilometer, (&Action{Action: "order.new"}).Encode())
+	require.Equal(t, 1, n)
+	require.Equal(t, "order.new", gjson.GetBytes(<-near.Send, "action").String())
+
+	require.NoError(t, near.User.appLogout("app", near))
+	require.Empty(t, s.Hub().UsersNear(center, 20*geo.Kilometer))
+}
@@ -0,0 +1,99 @@
+package ws
+
+// tenantKey 租户内的用户或主题键，租户ID为0时直接使用 id，与未启用多租户时一致
+type tenantKey struct {
+	tenantId uint
+	id       string
+}
+
+func scopedKey(tenantId uint, id string) any {
+	if tenantId == 0 {
+		return id
+	}
+
+	return tenantKey{tenantId: tenantId, id: id}
+}
+
+// TenantStats 租户在线统计
+type TenantStats struct {
+	TenantId uint `json:"tenantId"`
+	Users    int  `json:"users"`   //在线用户数
+	Guests   int  `json:"guests"`  //访客数
+	Clients  int  `json:"clients"` //连接数
+}
+
+// TenantUser 获取租户内的用户，租户ID为0时与 User 相同
+func (h *Hubc) TenantUser(tenantId uint, uid string) *User {
+	user, ok := h.Users.Load(scopedKey(tenantId, uid))
+	if ok {
+		return user.(*User)
+	}
+
+	return nil
+}
+
+// TenantUsers 租户内的全部用户
+func (h *Hubc) TenantUsers(tenantId uint) []*User {
+	var list []*User
+	h.Users.Range(func(key, value any) bool {
+		user, ok := value.(*User)
+		if ok && user != nil && user.TenantId == tenantId {
+			list = append(list, user)
+		}
+
+		return true
+	})
+
+	return list
+}
+
+// BroadcastTenant 向租户内的访客和用户发送广播
+func (h *Hubc) BroadcastTenant(tenantId uint, msg []byte) {
+	for _, g := range h.guests() {
+		if g.TenantId == tenantId {
+			g.SendMsg(msg)
+		}
+	}
+
+	for _, user := range h.TenantUsers(tenantId) {
+		user.SendMsg(msg)
+	}
+}
+
+// TenantStats 按租户统计在线用户、访客和连接数
+func (h *Hubc) TenantStats() map[uint]*TenantStats {
+	result := map[uint]*TenantStats{}
+	get := func(tenantId uint) *TenantStats {
+		stats, ok := result[tenantId]
+		if !ok {
+			stats = &TenantStats{TenantId: tenantId}
+			result[tenantId] = stats
+		}
+
+		return stats
+	}
+
+	for _, g := range h.guests() {
+		stats := get(g.TenantId)
+		stats.Guests++
+		stats.Clients++
+	}
+
+	h.Users.Range(func(key, value any) bool {
+		user, ok := value.(*User)
+		if !ok || user == nil {
+			return true
+		}
+
+		clients := len(user.Clients())
+		if clients > 0 {
+			stats := get(user.TenantId)
+			stats.Users++
+			stats.Clients += clients
+		}
+
+		return true
+	})
+
+	return result
+}
@@ -0,0 +1,103 @@
+package ws
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/wonli/aqi/store"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	s := NewInstance(http.NewServeMux())
+	defer s.Close()
+
+	r := s.Router()
+	r.Add("room.join", func(a *Context) { a.Sub("room") })
+	r.Add("room.say", func(a *Context) { a.Pub("room", a.Get("text")) })
+	r.Add("notice", func(a *Context) { a.Broadcast(&Action{Action: "notice"}) })
+	r.Add("dm", func(a *Context) { a.SendTo(a.Get("to"), "dm", H{}) })
+	r.Add("tenant", func(a *Context) {
+		tenantId, _ := store.TenantFrom(a.Context())
+		a.Send(H{"tenantId": tenantId})
+	})
+
+	next := func(c *Client) gjson.Result {
+		select {
+		case msg := <-c.Send:
+			return gjson.ParseBytes(msg)
+		case <-time.After(time.Second):
+			t.Fatal("no message")
+			return gjson.Result{}
+		}
+	}
+
+	empty := func(c *Client) {
+		select {
+		case msg := <-c.Send:
+			t.Fatalf("unexpected message %s", msg)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	a := &Client{Hub: s.Hub(), Send: make(chan []byte, 8), TenantId: 1}
+	b := &Client{Hub: s.Hub(), Send: make(chan []byte, 8), TenantId: 2}
+	guest := &Client{Hub: s.Hub(), Send: make(chan []byte, 8), TenantId: 2}
+	s.Hub().Connection <- guest
+
+	require.NoError(t, s.Hub().UserLogin("alice", "web", a))
+	require.NoError(t, s.Hub().UserLogin("alice", "web", b))
+	require.NotSame(t, a.User, b.User)
+	require.Same(t, b.User, s.Hub().TenantUser(2, "alice"))
+	require.Nil(t, s.Hub().User("alice"))
+	require.False(t, a.Disconnecting.Load())
+
+	Dispatcher(a, `{"action":"room.join"}`)
+	Dispatcher(b, `{"action":"room.join"}`)
+	Dispatcher(a, `{"action":"room.say","params":"{\"text\":\"hi\"}"}`)
+	res := next(a)
+	require.Equal(t, "room", res.Get("action").String())
+	require.Equal(t, "hi", res.Get("data.message").String())
+	empty(b)
+
+	Dispatcher(b, `{"action":"notice"}`)
+	require.Equal(t, "notice", next(b).Get("action").String())
+	require.Equal(t, "notice", next(guest).Get("action").String())
+	empty(a)
+
+	Dispatcher(b, `{"action":"tenant"}`)
+	require.Equal(t, int64(2), next(b).Get("data.tenantId").Int())
+
+	//私信只发给当前租户内的用户
+	Dispatcher(b, `{"action":"dm","params":"{\"to\":\"alice\"}"}`)
+	require.Equal(t, "dm", next(b).Get("action").String())
+	empty(a)
+
+	//封禁按租户隔离
+	_, err := s.TenantBan(2, "alice", time.Minute, "spam")
+	require.NoError(t, err)
+	require.Equal(t, "spam", next(b).Get("data.reason").String())
+	empty(a)
+	banned, _ := b.User.IsBanned()
+	require.True(t, banned)
+	banned, _ = a.User.IsBanned()
+	require.False(t, banned)
+
+	ban, err := s.BanOf("alice")
+	require.NoError(t, err)
+	require.Nil(t, ban)
+	ban, err = s.TenantBanOf(2, "alice")
+	require.NoError(t, err)
+	require.Equal(t, uint(2), ban.TenantId)
+
+	require.NoError(t, s.TenantUnban(2, "alice"))
+	banned, _ = b.User.IsBanned()
+	require.False(t, banned)
+
+	stats := s.Hub().TenantStats()
+	require.Equal(t, &TenantStats{TenantId: 1, Users: 1, Clients: 1}, stats[1])
+	require.Equal(t, &TenantStats{TenantId: 2, Users: 1, Guests: 1, Clients: 2}, stats[2])
+}
@@ -1,38 +1,53 @@
 package ws
 
 import (
+	"sort"
 	"sync"
 )
 
 type ActionManager struct {
+	mu         sync.RWMutex
 	handlerMap map[string]HandlersChain
 }
 
-var msy sync.Once
-var manager *ActionManager
-
-func InitManager() *ActionManager {
-	msy.Do(func() {
-		manager = &ActionManager{
-			handlerMap: map[string]HandlersChain{},
-		}
-
-		//处理websocket
-		go NewHubc().Run()
-	})
-
-	return manager
+func NewActionManager() *ActionManager {
+	return &ActionManager{
+		handlerMap: map[string]HandlersChain{},
+	}
 }
 
 func (m *ActionManager) Add(name string, router HandlersChain) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.handlerMap[name] = router
 }
 
 func (m *ActionManager) Has(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	_, ok := m.handlerMap[name]
 	return ok
 }
 
 func (m *ActionManager) Handlers(name string) HandlersChain {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	return m.handlerMap[name]
 }
+
+// Names 返回已注册的action名称，按名称排序
+func (m *ActionManager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.handlerMap))
+	for name := range m.handlerMap {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -9,35 +9,47 @@ import (
 type PubSub struct {
 	Topics        *sync.Map      //Topics map[string]*Topic //主题名称和Top对应map
 	TopicMsgQueue chan *TopicMsg //主题消息队列
+
+	hub  *Hubc
+	done chan struct{}
 }
 
 func NewPubSub() *PubSub {
 	return &PubSub{
 		Topics:        new(sync.Map),
 		TopicMsgQueue: make(chan *TopicMsg, 128),
+		done:          make(chan struct{}),
 	}
 }
 
-func (a *PubSub) initTopic(topicId string) *Topic {
+func (a *PubSub) initTopic(tenantId uint, topicId string) *Topic {
 	//主题不存在时先创建主题
-	topic, ok := a.Topics.Load(topicId)
-	if !ok {
-		t := &Topic{
-			Id:          topicId,
-			PubSub:      a,
-			SubUsers:    sync.Map{},
-			SubHandlers: sync.Map{},
-		}
+	topic, _ := a.Topics.LoadOrStore(scopedKey(tenantId, topicId), &Topic{
+		Id:          topicId,
+		TenantId:    tenantId,
+		PubSub:      a,
+		SubUsers:    sync.Map{},
+		SubHandlers: sync.Map{},
+	})
+
+	return topic.(*Topic)
+}
 
-		a.Topics.Store(topicId, t)
-		return t
+func (a *PubSub) topic(tenantId uint, topicId string) *Topic {
+	topic, ok := a.Topics.Load(scopedKey(tenantId, topicId))
+	if ok {
+		return topic.(*Topic)
 	}
 
-	return topic.(*Topic)
+	return nil
 }
 
-// Pub 发布主题
+// Pub 发布主题，租户主题使用 Tenant(tenantId).Pub
 func (a *PubSub) Pub(topicId string, data any) {
+	a.pub(0, topicId, data)
+}
+
+func (a *PubSub) pub(tenantId uint, topicId string, data any) {
 	msg := Action{
 		Action: topicId,
 		Data: H{
@@ -47,45 +59,67 @@ func (a *PubSub) Pub(topicId string, data any) {
 	}
 
 	//主题不存在时先创建主题
-	a.initTopic(topicId)
+	a.initTopic(tenantId, topicId)
 	a.TopicMsgQueue <- &TopicMsg{
-		Ori:     data,
-		TopicId: topicId,
-		Msg:     msg.Encode(),
+		Ori:      data,
+		TopicId:  topicId,
+		TenantId: tenantId,
+		Msg:      msg.Encode(),
 	}
 }
 
-// Sub 订阅主题
+// Sub 订阅主题，订阅用户所属租户的主题
 func (a *PubSub) Sub(topicId string, user *User) {
-	a.initTopic(topicId).AddSubUser(user)
+	a.initTopic(user.TenantId, topicId).AddSubUser(user)
 }
 
 // SubFunc 以函数方式订阅
 func (a *PubSub) SubFunc(topicId string, f func(msg *TopicMsg)) {
-	a.initTopic(topicId).AddSubHandle(f)
+	a.initTopic(0, topicId).AddSubHandle(f)
 }
 
 // Unsub 取消订阅主题
 func (a *PubSub) Unsub(topicId string, user *User) {
-	topic, ok := a.Topics.Load(topicId)
-	if ok {
-		topic.(*Topic).RemoveSubUser(user.Suid)
+	topic := a.topic(user.TenantId, topicId)
+	if topic != nil {
+		topic.RemoveSubUser(user.Suid)
 		user.UnsubTopic(topicId)
 	}
 }
 
 func (a *PubSub) Start() {
-	for msg := range a.TopicMsgQueue {
-		t, hasTopic := a.Topics.Load(msg.TopicId)
-		if !hasTopic {
-			logger.SugarLog.Info("未发布订阅主题收到消息")
-			continue
+	for {
+		select {
+		case msg := <-a.TopicMsgQueue:
+			t, hasTopic := a.Topics.Load(scopedKey(msg.TenantId, msg.TopicId))
+			if !hasTopic {
+				if logger.SugarLog != nil {
+					logger.SugarLog.Info("未发布订阅主题收到消息")
+				}
+				continue
+			}
+
+			//订阅消息的函数处理
+			t.(*Topic).ApplyFunc(msg)
+
+			//订阅消息的用户处理
+			t.(*Topic).SendToSubUser(msg.Msg)
+		case <-a.done:
+			return
 		}
+	}
+}
 
-		//订阅消息的函数处理
-		t.(*Topic).ApplyFunc(msg)
+// Stop 停止处理主题消息
+func (a *PubSub) Stop() {
+	close(a.done)
+}
 
-		//订阅消息的用户处理
-		t.(*Topic).SendToSubUser(msg.Msg)
+// user 从所属 Hubc 查找租户内的用户，未关联时使用默认 Server 的 Hubc
+func (a *PubSub) user(tenantId uint, uid string) *User {
+	if a.hub != nil {
+		return a.hub.TenantUser(tenantId, uid)
 	}
+
+	return Default().hub.TenantUser(tenantId, uid)
 }
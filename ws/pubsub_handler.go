@@ -1,17 +1,17 @@
 package ws
 
 func Pub(topic string, data any) {
-	Hub.PubSub.Pub(topic, data)
+	Default().hub.PubSub.Pub(topic, data)
 }
 
 func Sub(topicId string, user *User) {
-	Hub.PubSub.Sub(topicId, user)
+	Default().hub.PubSub.Sub(topicId, user)
 }
 
 func SubFunc(topicId string, f func(msg *TopicMsg)) {
-	Hub.PubSub.SubFunc(topicId, f)
+	Default().hub.PubSub.SubFunc(topicId, f)
 }
 
 func Unsub(topicId string, user *User) {
-	Hub.PubSub.Unsub(topicId, user)
+	Default().hub.PubSub.Unsub(topicId, user)
 }
@@ -0,0 +1,29 @@
+package ws
+
+// TenantPubSub 租户内的发布订阅，不同租户的同名主题互不影响
+type TenantPubSub struct {
+	pubsub   *PubSub
+	tenantId uint
+}
+
+// Tenant 租户内的发布订阅，租户ID为0时与直接使用 PubSub 相同
+//
+// 用户订阅时使用用户所属的租户，见 PubSub.Sub
+func (a *PubSub) Tenant(tenantId uint) *TenantPubSub {
+	return &TenantPubSub{pubsub: a, tenantId: tenantId}
+}
+
+// Pub 发布租户主题
+func (t *TenantPubSub) Pub(topicId string, data any) {
+	t.pubsub.pub(t.tenantId, topicId, data)
+}
+
+// SubFunc 以函数方式订阅租户主题
+func (t *TenantPubSub) SubFunc(topicId string, f func(msg *TopicMsg)) {
+	t.pubsub.initTopic(t.tenantId, topicId).AddSubHandle(f)
+}
+
+// Topic 获取租户主题，不存在时返回 nil
+func (t *TenantPubSub) Topic(topicId string) *Topic {
+	return t.pubsub.topic(t.tenantId, topicId)
+}
@@ -7,6 +7,7 @@ import (
 
 type Topic struct {
     Id          string   //订阅主题ID
+    TenantId    uint     //所属租户
     PubSub      *PubSub  //关联PubSub
     SubUsers    sync.Map //SubUsers map[string]*time.Time //订阅用户uniqueId和订阅时间
     SubHandlers sync.Map //SubHandlers map[string]func(msg *TopicMsg) //内部组件间通知
@@ -29,7 +30,7 @@ func (a *Topic) RemoveSubUser(suid string) {
 func (a *Topic) SendToSubUser(msg []byte) {
 	a.SubUsers.Range(func(key, value any) bool {
 		uniqueId := key.(string)
-		user := Hub.User(uniqueId)
+		user := a.PubSub.user(a.TenantId, uniqueId)
 		if user != nil {
 			user.SendMsg(msg)
 		}
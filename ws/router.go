@@ -14,15 +14,15 @@ type IRouter interface {
 }
 
 type Routers struct {
+	server         *Server
 	manager        *ActionManager
 	handlerMembers HandlersChain
 	groups         []string
 }
 
+// NewRouter 创建注册到默认 Server 的路由
 func NewRouter() Routers {
-	return Routers{
-		manager: InitManager(),
-	}
+	return Default().Router()
 }
 
 func (r Routers) Add(name string, fn ...HandlerFunc) {
@@ -50,3 +50,8 @@ func (r Routers) Group(name string) IRouter {
 	r.groups = append(r.groups, name)
 	return r
 }
+
+// Server 返回路由注册到的 Server
+func (r Routers) Server() *Server {
+	return r.server
+}
@@ -2,7 +2,10 @@ package ws
 
 import (
 	"net/http"
+	"path/filepath"
 	"sync"
+
+	"github.com/wonli/aqi/logger"
 )
 
 type Server struct {
@@ -13,23 +16,73 @@ type Server struct {
 	port     string
 	isDev    bool
 	dataPath string
+
+	langMu    sync.Mutex
+	langStore LangStore
+	lang      *langCache
+
+	hub       *Hubc
+	manager   *ActionManager
+	admission admission
+
+	heartbeatMu sync.Mutex
+	heartbeat   HeartbeatPolicy
+
+	banMu sync.Mutex
+	bans  BanStore
+
+	sessionMu       sync.RWMutex
+	sessionPolicies map[string]SessionPolicy
+
+	presenceMu sync.Mutex
+	presence   PresencePolicy
 }
 
-var (
-	wss  *Server
-	once sync.Once
-)
+// NewInstance 创建独立的 Server 实例，拥有自己的 Hubc、路由和发布订阅
+//
+// 同一进程中可以创建多个互不影响的 Server，如应用和管理后台分别使用不同的websocket入口
+func NewInstance(engine http.Handler) *Server {
+	s := &Server{
+		engine:  engine,
+		hub:     NewHub(),
+		manager: NewActionManager(),
+	}
 
-func NewServer(engine http.Handler) *Server {
-	once.Do(func() {
-		InitManager()
-		wss = &Server{
-			engine: engine,
-			fn:     HttpHandler,
-		}
-	})
+	s.fn = s.HttpHandler
+	s.hub.server = s
+	s.manager.Add("sys.setStatus", HandlersChain{StatusHandler})
+	go s.hub.Run()
+	return s
+}
+
+// Hub 客户端和用户管理
+func (s *Server) Hub() *Hubc {
+	return s.hub
+}
+
+// Manager 路由表
+func (s *Server) Manager() *ActionManager {
+	return s.manager
+}
+
+// Router 创建注册到当前 Server 的路由
+func (s *Server) Router() Routers {
+	return Routers{
+		server:  s,
+		manager: s.manager,
+	}
+}
+
+// Close 停止 Hubc 和语言包监听
+func (s *Server) Close() {
+	s.hub.Stop()
 
-	return wss
+	s.langMu.Lock()
+	defer s.langMu.Unlock()
+
+	if w, ok := s.langStore.(LangWatcher); ok && s.lang != nil {
+		_ = w.Close()
+	}
 }
 
 func (s *Server) Handler(fn http.HandlerFunc) {
@@ -48,6 +101,50 @@ func (s *Server) SetIsDev(dev bool) {
 	s.isDev = dev
 }
 
+// SetLangStore 设置语言包存储，默认使用 {dataPath}/i18n 目录下的yaml文件
+func (s *Server) SetLangStore(store LangStore) {
+	s.langMu.Lock()
+	defer s.langMu.Unlock()
+
+	if w, ok := s.langStore.(LangWatcher); ok && s.lang != nil {
+		_ = w.Close()
+	}
+
+	s.langStore = store
+	s.lang = nil
+}
+
+// ReloadLanguage 重新加载语言包，不指定语言时重新加载全部
+func (s *Server) ReloadLanguage(langs ...string) {
+	s.language().reload(langs...)
+}
+
+func (s *Server) language() *langCache {
+	s.langMu.Lock()
+	defer s.langMu.Unlock()
+
+	if s.lang != nil {
+		return s.lang
+	}
+
+	if s.langStore == nil {
+		s.langStore = NewFileLangStore(filepath.Join(s.dataPath, "i18n"))
+	}
+
+	cache := newLangCache(s.langStore)
+	if w, ok := s.langStore.(LangWatcher); ok {
+		err := w.Watch(func(lang string) {
+			cache.reload(lang)
+		})
+		if err != nil {
+			logger.SugarLog.Errorf("Failed to watch language store: %s", err.Error())
+		}
+	}
+
+	s.lang = cache
+	return cache
+}
+
 func (s *Server) Init() {
 
 }
@@ -0,0 +1,229 @@
+package ws
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/wonli/aqi/utils/ip"
+)
+
+// 拒绝连接的原因
+const (
+	RejectOrigin         = "origin"
+	RejectMaxConnections = "maxConnections"
+	RejectMaxPerIP       = "maxPerIp"
+	RejectMaxPerUser     = "maxPerUser"
+	RejectHandshake      = "handshake"
+)
+
+// ErrTooManyUserConnections 用户连接数超过 AdmissionPolicy.MaxPerUser
+var ErrTooManyUserConnections = errors.New("too many connections for user")
+
+// AdmissionPolicy 连接准入策略，数值为0时不限制
+type AdmissionPolicy struct {
+	//允许的Origin，支持 https://app.example.com、app.example.com、*.example.com 和 *
+	//为空时不校验，未携带Origin的非浏览器客户端不受限制
+	AllowedOrigins []string
+
+	MaxConnections int //最大连接数
+	MaxPerIP       int //单个IP最大连接数
+	MaxPerUser     int //单个用户最大登录客户端数
+
+	//握手回调，返回错误时拒绝连接，返回 *RejectError 可指定HTTP状态码
+	Handshake func(r *http.Request) error
+}
+
+// RejectError 拒绝连接的HTTP状态码和原因
+type RejectError struct {
+	Status int
+	Reason string
+}
+
+func (e *RejectError) Error() string {
+	return e.Reason
+}
+
+// Reject 在握手回调中拒绝连接
+func Reject(status int, reason string) *RejectError {
+	return &RejectError{Status: status, Reason: reason}
+}
+
+// AdmissionStats 当前连接数和按原因统计的拒绝次数
+type AdmissionStats struct {
+	Active   int              `json:"active"`
+	Rejected map[string]int64 `json:"rejected"`
+}
+
+type admission struct {
+	mu       sync.Mutex
+	policy   AdmissionPolicy
+	active   int
+	perIP    map[string]int
+	rejected map[string]int64
+}
+
+// SetAdmission 设置连接准入策略，对websocket、SSE和长轮询连接生效
+func (s *Server) SetAdmission(policy AdmissionPolicy) {
+	s.admission.mu.Lock()
+	defer s.admission.mu.Unlock()
+
+	s.admission.policy = policy
+}
+
+// AdmissionStats 连接准入统计
+func (s *Server) AdmissionStats() AdmissionStats {
+	a := &s.admission
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := AdmissionStats{
+		Active:   a.active,
+		Rejected: make(map[string]int64, len(a.rejected)),
+	}
+
+	for reason, n := range a.rejected {
+		stats.Rejected[reason] = n
+	}
+
+	return stats
+}
+
+// admit 校验连接，拒绝时写入HTTP响应并返回false
+//
+// 通过时返回的 release 需要在连接断开后调用，多次调用只生效一次
+func (s *Server) admit(w http.ResponseWriter, r *http.Request) (release func(), ok bool) {
+	a := &s.admission
+	a.mu.Lock()
+	policy := a.policy
+	a.mu.Unlock()
+
+	if !originAllowed(r.Header.Get("Origin"), policy.AllowedOrigins) {
+		s.reject(w, RejectOrigin, http.StatusForbidden, "origin not allowed")
+		return nil, false
+	}
+
+	if policy.Handshake != nil {
+		err := policy.Handshake(r)
+		if err != nil {
+			status := http.StatusForbidden
+			var re *RejectError
+			if errors.As(err, &re) && re.Status > 0 {
+				status = re.Status
+			}
+
+			s.reject(w, RejectHandshake, status, err.Error())
+			return nil, false
+		}
+	}
+
+	ipAddr := ip.GetIPAddress(r)
+
+	a.mu.Lock()
+	if policy.MaxConnections > 0 && a.active >= policy.MaxConnections {
+		a.mu.Unlock()
+		s.reject(w, RejectMaxConnections, http.StatusServiceUnavailable, "too many connections")
+		return nil, false
+	}
+
+	if policy.MaxPerIP > 0 && a.perIP[ipAddr] >= policy.MaxPerIP {
+		a.mu.Unlock()
+		s.reject(w, RejectMaxPerIP, http.StatusTooManyRequests, "too many connections from this address")
+		return nil, false
+	}
+
+	if a.perIP == nil {
+		a.perIP = map[string]int{}
+	}
+
+	a.active++
+	a.perIP[ipAddr]++
+	a.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+
+			a.active--
+			a.perIP[ipAddr]--
+			if a.perIP[ipAddr] <= 0 {
+				delete(a.perIP, ipAddr)
+			}
+		})
+	}, true
+}
+
+// admitUser 校验用户登录客户端数，调用方持有用户锁，登录会替换旧连接时不受限制
+func (s *Server) admitUser(clients, kicked []*Client, client *Client) error {
+	a := &s.admission
+	a.mu.Lock()
+	max := a.policy.MaxPerUser
+	a.mu.Unlock()
+
+	if max <= 0 || len(kicked) > 0 || slices.Contains(clients, client) {
+		return nil
+	}
+
+	if len(clients) >= max {
+		s.countReject(RejectMaxPerUser)
+		return ErrTooManyUserConnections
+	}
+
+	return nil
+}
+
+func (s *Server) reject(w http.ResponseWriter, reason string, status int, msg string) {
+	s.countReject(reason)
+	http.Error(w, msg, status)
+}
+
+func (s *Server) countReject(reason string) {
+	a := &s.admission
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.rejected == nil {
+		a.rejected = map[string]int64{}
+	}
+
+	a.rejected[reason]++
+}
+
+// originAllowed 校验Origin，未配置或未携带Origin时允许
+func originAllowed(origin string, allowed []string) bool {
+	if len(allowed) == 0 || origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "*":
+			return true
+		case strings.Contains(pattern, "://"):
+			if strings.EqualFold(strings.TrimSuffix(pattern, "/"), u.Scheme+"://"+u.Host) {
+				return true
+			}
+		case strings.HasPrefix(pattern, "*."):
+			if strings.HasSuffix(host, pattern[1:]) {
+				return true
+			}
+		case pattern == host || pattern == strings.ToLower(u.Host):
+			return true
+		}
+	}
+
+	return false
+}
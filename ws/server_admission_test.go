@@ -0,0 +1,139 @@
+package ws
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmission(t *testing.T) {
+	s := NewInstance(http.NewServeMux())
+	defer s.Close()
+
+	s.SetAdmission(AdmissionPolicy{
+		AllowedOrigins: []string{"https://app.example.com", "*.example.org"},
+		MaxPerIP:       1,
+		Handshake: func(r *http.Request) error {
+			if r.URL.Query().Get("token") != "secret" {
+				return Reject(http.StatusUnauthorized, "invalid token")
+			}
+
+			return nil
+		},
+	})
+
+	svr := httptest.NewServer(http.HandlerFunc(s.SSEHandler))
+	defer svr.Close()
+
+	open := func(origin, token string) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, svr.URL+"?token="+token, nil)
+		require.NoError(t, err)
+		request.Header.Set("Origin", origin)
+
+		res, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		return res
+	}
+
+	res := open("https://evil.com", "secret")
+	res.Body.Close()
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	res = open("https://app.example.com", "bad")
+	res.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	stream := open("https://app.example.com", "secret")
+	require.Equal(t, http.StatusOK, stream.StatusCode)
+	event, _ := readSSEEvent(t, bufio.NewReader(stream.Body))
+	require.Equal(t, "session", event)
+	require.Equal(t, 1, s.AdmissionStats().Active)
+
+	res = open("https://a.example.org", "secret")
+	res.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+
+	stream.Body.Close()
+	require.Eventually(t, func() bool {
+		return s.AdmissionStats().Active == 0
+	}, time.Second, 10*time.Millisecond)
+
+	stats := s.AdmissionStats()
+	require.Equal(t, int64(1), stats.Rejected[RejectOrigin])
+	require.Equal(t, int64(1), stats.Rejected[RejectHandshake])
+	require.Equal(t, int64(1), stats.Rejected[RejectMaxPerIP])
+}
+
+func TestAdmissionMaxPerUser(t *testing.T) {
+	s := NewInstance(http.NewServeMux())
+	defer s.Close()
+
+	s.SetAdmission(AdmissionPolicy{MaxPerUser: 1})
+
+	c1 := &Client{Hub: s.Hub(), Send: make(chan []byte, 8)}
+	c2 := &Client{Hub: s.Hub(), Send: make(chan []byte, 8)}
+	c3 := &Client{Hub: s.Hub(), Send: make(chan []byte, 8)}
+	require.NoError(t, s.Hub().UserLogin("u1", "web", c1))
+	require.ErrorIs(t, s.Hub().UserLogin("u1", "ios", c2), ErrTooManyUserConnections)
+	require.NoError(t, s.Hub().UserLogin("u1", "web", c3))
+	require.Equal(t, int64(1), s.AdmissionStats().Rejected[RejectMaxPerUser])
+
+	// 并发登录不能超过限制
+	var wg sync.WaitGroup
+	var admitted atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(appId string) {
+			defer wg.Done()
+			c := &Client{Hub: s.Hub(), Send: make(chan []byte, 8)}
+			if s.Hub().UserLogin("u2", appId, c) == nil {
+				admitted.Add(1)
+			}
+		}(fmt.Sprintf("app%d", i))
+	}
+
+	wg.Wait()
+	require.Equal(t, int32(1), admitted.Load())
+	require.Len(t, s.Hub().User("u2").Clients(), 1)
+}
+
+func TestAdmissionMaxConnections(t *testing.T) {
+	s := NewInstance(http.NewServeMux())
+	defer s.Close()
+
+	s.SetAdmission(AdmissionPolicy{MaxConnections: 1})
+
+	request := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	release, ok := s.admit(httptest.NewRecorder(), request)
+	require.True(t, ok)
+
+	recorder := httptest.NewRecorder()
+	_, ok = s.admit(recorder, request)
+	require.False(t, ok)
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	release()
+	release()
+	require.Equal(t, 0, s.AdmissionStats().Active)
+}
+
+func TestOriginAllowed(t *testing.T) {
+	allowed := []string{"https://app.example.com", "admin.example.com", "*.example.org"}
+
+	require.True(t, originAllowed("", allowed))
+	require.True(t, originAllowed("https://anything", nil))
+	require.True(t, originAllowed("https://app.example.com", allowed))
+	require.False(t, originAllowed("http://app.example.com", allowed))
+	require.True(t, originAllowed("http://admin.example.com", allowed))
+	require.True(t, originAllowed("https://a.b.example.org", allowed))
+	require.False(t, originAllowed("https://example.org.evil.com", allowed))
+	require.False(t, originAllowed("null", allowed))
+	require.True(t, originAllowed("https://x.com", []string{"*"}))
+}
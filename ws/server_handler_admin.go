@@ -0,0 +1,391 @@
+package ws
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClientInfo 管理接口返回的客户端信息
+type ClientInfo struct {
+	Id                uint64    `json:"id"`
+	ClientId          string    `json:"clientId,omitempty"`
+	TenantId          uint      `json:"tenantId,omitempty"`
+	Uid               string    `json:"uid,omitempty"`
+	AppId             string    `json:"appId,omitempty"`
+	Platform          string    `json:"platform,omitempty"`
+	Version           string    `json:"version,omitempty"`
+	Transport         string    `json:"transport,omitempty"`
+	Endpoint          string    `json:"endpoint,omitempty"`
+	IpAddress         string    `json:"ipAddress"`
+	IpAddressPort     string    `json:"ipAddressPort"`
+	IpLocation        string    `json:"ipLocation,omitempty"`
+	IsLogin           bool      `json:"isLogin"`
+	ConnectionTime    time.Time `json:"connectionTime"`
+	LastRequestTime   time.Time `json:"lastRequestTime"`
+	LastHeartbeatTime time.Time `json:"lastHeartbeatTime"`
+	Logs              []string  `json:"logs,omitempty"`
+}
+
+// UserInfo 管理接口返回的用户信息
+type UserInfo struct {
+	Suid              string        `json:"suid"`
+	TenantId          uint          `json:"tenantId,omitempty"`
+	Nickname          string        `json:"nickname,omitempty"`
+	Online            bool          `json:"online"`
+	Ban               *Ban          `json:"ban,omitempty"`
+	LastHeartbeatTime time.Time     `json:"lastHeartbeatTime"`
+	Clients           []*ClientInfo `json:"clients"`
+	Topics            []string      `json:"topics"`
+}
+
+// TopicInfo 管理接口返回的主题信息
+type TopicInfo struct {
+	Id          string `json:"id"`
+	TenantId    uint   `json:"tenantId,omitempty"`
+	Subscribers int    `json:"subscribers"`
+}
+
+// AdminHandler 管理接口，请求需携带 Authorization: Bearer {token}，token为空时拒绝全部请求
+//
+//	GET    /clients                 连接列表，支持 uid、tenant、appId、ip、transport、login 参数过滤
+//	GET    /clients/{id}            连接详情和最近日志
+//	DELETE /clients/{id}            断开连接
+//	GET    /users/{uid}             用户在线状态和客户端
+//	DELETE /users/{uid}             断开用户全部连接
+//	POST   /users/{uid}/ban         封禁，请求体 {"duration":"10m","reason":"spam","actions":["chat.*"]}
+//	DELETE /users/{uid}/ban         解除封禁
+//	POST   /users/{uid}/send        发送消息，请求体 {"action":"notice","data":{},"appId":""}
+//	GET    /topics                  主题列表和订阅人数
+//	POST   /topics/{id}/pub         发布主题消息，请求体 {"data":{}}
+//
+// 用户和主题接口通过 tenant 参数指定租户，挂载到子路径时使用 http.StripPrefix
+func (s *Server) AdminHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /clients", s.adminClients)
+	mux.HandleFunc("GET /clients/{id}", s.adminClient)
+	mux.HandleFunc("DELETE /clients/{id}", s.adminKickClient)
+	mux.HandleFunc("GET /users/{uid}", s.adminUser)
+	mux.HandleFunc("DELETE /users/{uid}", s.adminKickUser)
+	mux.HandleFunc("POST /users/{uid}/ban", s.adminBan)
+	mux.HandleFunc("DELETE /users/{uid}/ban", s.adminUnban)
+	mux.HandleFunc("POST /users/{uid}/send", s.adminSend)
+	mux.HandleFunc("GET /topics", s.adminTopics)
+	mux.HandleFunc("POST /topics/{id}/pub", s.adminPub)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !adminAuthorized(r, token) {
+			writeApiData(w, http.StatusUnauthorized, &ApiData{Code: ErrUncertified.Code, Msg: "unauthorized"})
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func adminAuthorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) == 1
+}
+
+func (s *Server) adminClients(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	list := []*ClientInfo{}
+	for _, c := range s.hub.clients() {
+		info := newClientInfo(c, false)
+		if v := q.Get("uid"); v != "" && info.Uid != v {
+			continue
+		}
+
+		if v := q.Get("tenant"); v != "" && strconv.FormatUint(uint64(info.TenantId), 10) != v {
+			continue
+		}
+
+		if v := q.Get("appId"); v != "" && info.AppId != v {
+			continue
+		}
+
+		if v := q.Get("ip"); v != "" && info.IpAddress != v {
+			continue
+		}
+
+		if v := q.Get("transport"); v != "" && info.Transport != v {
+			continue
+		}
+
+		if v := q.Get("login"); v != "" && strconv.FormatBool(info.IsLogin) != v {
+			continue
+		}
+
+		list = append(list, info)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Id < list[j].Id
+	})
+
+	writeApiData(w, http.StatusOK, &ApiData{Data: list})
+}
+
+func (s *Server) adminClient(w http.ResponseWriter, r *http.Request) {
+	c := s.adminFindClient(w, r)
+	if c != nil {
+		writeApiData(w, http.StatusOK, &ApiData{Data: newClientInfo(c, true)})
+	}
+}
+
+func (s *Server) adminKickClient(w http.ResponseWriter, r *http.Request) {
+	c := s.adminFindClient(w, r)
+	if c != nil {
+		c.Log("xx", "Kicked by admin")
+		s.hub.disconnect(c)
+		writeApiData(w, http.StatusOK, &ApiData{})
+	}
+}
+
+func (s *Server) adminUser(w http.ResponseWriter, r *http.Request) {
+	user := s.adminFindUser(w, r)
+	if user == nil {
+		return
+	}
+
+	info := &UserInfo{
+		Suid:              user.Suid,
+		TenantId:          user.TenantId,
+		Nickname:          user.Nickname,
+		Online:            user.IsOnline(),
+		Ban:               user.ActiveBan(),
+		LastHeartbeatTime: user.LastHeartbeatTime,
+		Clients:           []*ClientInfo{},
+		Topics:            []string{},
+	}
+
+	for _, c := range user.Clients() {
+		info.Clients = append(info.Clients, newClientInfo(c, false))
+	}
+
+	user.RLock()
+	for topicId := range user.SubTopics {
+		info.Topics = append(info.Topics, topicId)
+	}
+	user.RUnlock()
+
+	sort.Strings(info.Topics)
+	writeApiData(w, http.StatusOK, &ApiData{Data: info})
+}
+
+func (s *Server) adminKickUser(w http.ResponseWriter, r *http.Request) {
+	user := s.adminFindUser(w, r)
+	if user == nil {
+		return
+	}
+
+	clients := user.Clients()
+	for _, c := range clients {
+		c.Log("xx", "Kicked by admin")
+		s.hub.disconnect(c)
+	}
+
+	writeApiData(w, http.StatusOK, &ApiData{Data: H{"kicked": len(clients)}})
+}
+
+func (s *Server) adminBan(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Duration string   `json:"duration"`
+		Reason   string   `json:"reason"`
+		Actions  []string `json:"actions"`
+	}
+
+	if !adminBind(w, r, &body) {
+		return
+	}
+
+	var d time.Duration
+	if body.Duration != "" {
+		var err error
+		d, err = time.ParseDuration(body.Duration)
+		if err != nil || d <= 0 {
+			writeApiData(w, http.StatusBadRequest, &ApiData{Code: ErrParamsInvalid.Code, Msg: "invalid duration"})
+			return
+		}
+	}
+
+	ban, err := s.TenantBan(adminTenant(r), r.PathValue("uid"), d, body.Reason, body.Actions...)
+	if err != nil {
+		writeApiData(w, http.StatusInternalServerError, &ApiData{Code: ErrServerError.Code, Msg: err.Error()})
+		return
+	}
+
+	writeApiData(w, http.StatusOK, &ApiData{Data: H{"ban": ban}})
+}
+
+func (s *Server) adminUnban(w http.ResponseWriter, r *http.Request) {
+	err := s.TenantUnban(adminTenant(r), r.PathValue("uid"))
+	if err != nil {
+		writeApiData(w, http.StatusInternalServerError, &ApiData{Code: ErrServerError.Code, Msg: err.Error()})
+		return
+	}
+
+	writeApiData(w, http.StatusOK, &ApiData{})
+}
+
+func (s *Server) adminSend(w http.ResponseWriter, r *http.Request) {
+	user := s.adminFindUser(w, r)
+	if user == nil {
+		return
+	}
+
+	var body struct {
+		Action string          `json:"action"`
+		AppId  string          `json:"appId"`
+		Data   json.RawMessage `json:"data"`
+	}
+
+	if !adminBind(w, r, &body) {
+		return
+	}
+
+	if body.Action == "" {
+		writeApiData(w, http.StatusBadRequest, &ApiData{Code: ErrParamsInvalid.Code, Msg: "action is required"})
+		return
+	}
+
+	msg := &Action{Action: body.Action}
+	if len(body.Data) > 0 {
+		msg.Data = body.Data
+	}
+
+	if body.AppId != "" {
+		user.SendMsgToApp(body.AppId, msg.Encode())
+	} else {
+		user.SendMsg(msg.Encode())
+	}
+
+	writeApiData(w, http.StatusOK, &ApiData{})
+}
+
+func (s *Server) adminTopics(w http.ResponseWriter, r *http.Request) {
+	list := []*TopicInfo{}
+	s.hub.PubSub.Topics.Range(func(key, value any) bool {
+		topic := value.(*Topic)
+		if v := r.URL.Query().Get("tenant"); v != "" && strconv.FormatUint(uint64(topic.TenantId), 10) != v {
+			return true
+		}
+
+		info := &TopicInfo{Id: topic.Id, TenantId: topic.TenantId}
+		topic.SubUsers.Range(func(key, value any) bool {
+			info.Subscribers++
+			return true
+		})
+
+		list = append(list, info)
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TenantId != list[j].TenantId {
+			return list[i].TenantId < list[j].TenantId
+		}
+
+		return list[i].Id < list[j].Id
+	})
+
+	writeApiData(w, http.StatusOK, &ApiData{Data: list})
+}
+
+func (s *Server) adminPub(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+
+	if !adminBind(w, r, &body) {
+		return
+	}
+
+	s.hub.PubSub.Tenant(adminTenant(r)).Pub(r.PathValue("id"), body.Data)
+	writeApiData(w, http.StatusOK, &ApiData{})
+}
+
+func (s *Server) adminFindClient(w http.ResponseWriter, r *http.Request) *Client {
+	id, _ := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	for _, c := range s.hub.clients() {
+		if c.ConnId() == id {
+			return c
+		}
+	}
+
+	writeApiData(w, http.StatusNotFound, &ApiData{Code: -1006, Msg: "client not found"})
+	return nil
+}
+
+func (s *Server) adminFindUser(w http.ResponseWriter, r *http.Request) *User {
+	user := s.hub.TenantUser(adminTenant(r), r.PathValue("uid"))
+	if user == nil {
+		writeApiData(w, http.StatusNotFound, &ApiData{Code: -1006, Msg: "user not found"})
+	}
+
+	return user
+}
+
+// adminTenant tenant 参数指定的租户，未指定时为0
+func adminTenant(r *http.Request) uint {
+	tenantId, _ := strconv.ParseUint(r.URL.Query().Get("tenant"), 10, 64)
+	return uint(tenantId)
+}
+
+func adminBind(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, apiMaxBodySize))
+	if err == nil {
+		err = json.Unmarshal(body, v)
+	}
+
+	if err != nil {
+		writeApiData(w, http.StatusBadRequest, &ApiData{Code: ErrParamsInvalid.Code, Msg: ErrParamsInvalid.Msg})
+		return false
+	}
+
+	return true
+}
+
+func newClientInfo(c *Client, withLogs bool) *ClientInfo {
+	info := &ClientInfo{
+		Id:                c.ConnId(),
+		ClientId:          c.ClientId,
+		TenantId:          c.TenantId,
+		AppId:             c.AppId,
+		Platform:          c.Platform,
+		Version:           c.Version,
+		Transport:         c.Transport,
+		Endpoint:          c.Endpoint,
+		IpAddress:         c.IpAddress,
+		IpAddressPort:     c.IpAddressPort,
+		IpLocation:        c.IpLocation,
+		IsLogin:           c.IsLogin,
+		ConnectionTime:    c.ConnectionTime,
+		LastRequestTime:   c.LastRequestTime,
+		LastHeartbeatTime: c.LastHeartbeatTime,
+	}
+
+	if c.User != nil {
+		info.Uid = c.User.Suid
+	}
+
+	if withLogs {
+		info.Logs = c.GetRecentLogs()
+	}
+
+	return info
+}
@@ -0,0 +1,111 @@
+package ws
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestAdminHandler(t *testing.T) {
+	s := NewInstance(http.NewServeMux())
+	defer s.Close()
+
+	admin := s.AdminHandler("secret")
+	call := func(method, path, token, body string) (gjson.Result, int) {
+		request := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		recorder := httptest.NewRecorder()
+		admin.ServeHTTP(recorder, request)
+		return gjson.Parse(recorder.Body.String()), recorder.Code
+	}
+
+	guest := &Client{Hub: s.Hub(), Send: make(chan []byte, 8), IpAddress: "10.0.0.1"}
+	s.Hub().Connection <- guest
+
+	alice := &Client{Hub: s.Hub(), Send: make(chan []byte, 8), IpAddress: "10.0.0.2"}
+	require.NoError(t, s.Hub().UserLogin("alice", "web", alice))
+	alice.Log("<-", "hello")
+	s.Hub().PubSub.Sub("room", alice.User)
+
+	_, status := call(http.MethodGet, "/clients", "", "")
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	_, status = call(http.MethodGet, "/clients", "wrong", "")
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	res, status := call(http.MethodGet, "/clients", "secret", "")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, res.Get("data").Array(), 2)
+
+	res, _ = call(http.MethodGet, "/clients?uid=alice", "secret", "")
+	require.Len(t, res.Get("data").Array(), 1)
+	require.Equal(t, "web", res.Get("data.0.appId").String())
+
+	res, _ = call(http.MethodGet, "/clients?login=false", "secret", "")
+	require.Equal(t, "10.0.0.1", res.Get("data.0.ipAddress").String())
+
+	id := strconv.FormatUint(alice.ConnId(), 10)
+	res, status = call(http.MethodGet, "/clients/"+id, "secret", "")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, res.Get("data.logs.0").String(), "hello")
+
+	_, status = call(http.MethodGet, "/clients/0", "secret", "")
+	require.Equal(t, http.StatusNotFound, status)
+
+	res, _ = call(http.MethodGet, "/users/alice", "secret", "")
+	require.True(t, res.Get("data.online").Bool())
+	require.Equal(t, "room", res.Get("data.topics.0").String())
+
+	res, _ = call(http.MethodGet, "/topics", "secret", "")
+	require.Equal(t, int64(1), res.Get(`data.#(id=="room").subscribers`).Int())
+
+	res, status = call(http.MethodPost, "/users/alice/ban", "secret", `{"duration":"10m","reason":"spam"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "spam", res.Get("data.ban.reason").String())
+	banned, _ := alice.User.IsBanned()
+	require.True(t, banned)
+	require.Equal(t, "sys.ban", gjson.GetBytes(<-alice.Send, "action").String())
+
+	_, status = call(http.MethodPost, "/users/alice/ban", "secret", `{"duration":"soon"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	_, status = call(http.MethodDelete, "/users/alice/ban", "secret", "")
+	require.Equal(t, http.StatusOK, status)
+	banned, _ = alice.User.IsBanned()
+	require.False(t, banned)
+
+	_, status = call(http.MethodPost, "/users/alice/send", "secret", `{"action":"notice","data":{"text":"hi"}}`)
+	require.Equal(t, http.StatusOK, status)
+	msg := <-alice.Send
+	require.Equal(t, "notice", gjson.GetBytes(msg, "action").String())
+	require.Equal(t, "hi", gjson.GetBytes(msg, "data.text").String())
+
+	_, status = call(http.MethodPost, "/topics/room/pub", "secret", `{"data":{"text":"all"}}`)
+	require.Equal(t, http.StatusOK, status)
+	select {
+	case msg = <-alice.Send:
+		require.Equal(t, "all", gjson.GetBytes(msg, "data.message.text").String())
+	case <-time.After(time.Second):
+		t.Fatal("topic message not delivered")
+	}
+
+	res, status = call(http.MethodDelete, "/users/alice", "secret", "")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(1), res.Get("data.kicked").Int())
+	require.Eventually(t, func() bool {
+		res, _ := call(http.MethodGet, "/clients?uid=alice", "secret", "")
+		return len(res.Get("data").Array()) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	_, status = call(http.MethodGet, "/users/nobody", "secret", "")
+	require.Equal(t, http.StatusNotFound, status)
+}
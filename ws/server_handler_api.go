@@ -0,0 +1,203 @@
+package ws
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/wonli/aqi/utils/ip"
+)
+
+// 请求体最大长度
+const apiMaxBodySize = 4 << 20
+
+// ApiHandler 通过HTTP调用已注册的action
+//
+// 路径最后一段为action名称，请求体作为params，例如:
+//
+//	mux.HandleFunc("/api/", ws.ApiHandler)
+//	engine.POST("/api/:action", gin.WrapF(ws.ApiHandler))
+//
+// 每个请求使用一个临时客户端执行与websocket相同的中间件和处理函数，
+// 处理结果以 ApiData JSON 返回
+func (s *Server) ApiHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeApiData(w, http.StatusMethodNotAllowed, &ApiData{Code: -1005, Msg: "method not allowed"})
+		return
+	}
+
+	action := path.Base(r.URL.Path)
+	if action == "" || action == "." || action == "/" || action == "ping" || !s.manager.Has(action) {
+		writeApiData(w, http.StatusNotFound, &ApiData{Code: -1005, Msg: "request not supported"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, apiMaxBodySize))
+	if err != nil {
+		writeApiData(w, http.StatusRequestEntityTooLarge, &ApiData{Code: ErrParamsInvalid.Code, Msg: err.Error()})
+		return
+	}
+
+	params := string(body)
+	if len(body) == 0 {
+		params = "{}"
+	} else if !gjson.Valid(params) {
+		writeApiData(w, http.StatusBadRequest, &ApiData{Code: ErrParamsInvalid.Code, Msg: ErrParamsInvalid.Msg})
+		return
+	}
+
+	res, status := s.call(w, r, action, params)
+	writeApiData(w, status, res)
+}
+
+// Call 使用临时客户端执行action，与 ApiHandler 经过相同的中间件和处理函数
+//
+// r 提供请求上下文、请求头和客户端地址，params 为JSON参数，
+// 返回结果的 HttpStatus 为 ApiHandler 会使用的HTTP状态码
+func (s *Server) Call(r *http.Request, action, params string) *ApiData {
+	if action == "ping" || !s.manager.Has(action) {
+		return &ApiData{Code: -1005, Msg: "request not supported", HttpStatus: http.StatusNotFound}
+	}
+
+	if params == "" {
+		params = "{}"
+	}
+
+	res, _ := s.call(discardWriter{header: http.Header{}}, r, action, params)
+	return res
+}
+
+// discardWriter Call 没有真实的HTTP响应，处理函数写入的内容被丢弃
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w discardWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w discardWriter) WriteHeader(int) {}
+
+func (s *Server) call(w http.ResponseWriter, r *http.Request, action, params string) (*ApiData, int) {
+	c, recorder := s.newApiClient(w, r)
+	ctx := dispatch(c, request{
+		Id:     r.Header.Get("X-Request-Id"),
+		Action: action,
+		Params: params,
+	})
+
+	res, status := apiResponse(ctx, action, recorder.close(c))
+	res.HttpStatus = status
+	return res, status
+}
+
+func (s *Server) newApiClient(w http.ResponseWriter, r *http.Request) (*Client, *apiRecorder) {
+	ipAddr := ip.GetIPAddress(r)
+	c := &Client{
+		Hub:            s.hub,
+		Send:           make(chan []byte, 32),
+		Endpoint:       r.URL.Path,
+		IpAddress:      ipAddr,
+		IpAddressPort:  r.RemoteAddr,
+		ConnectionTime: time.Now(),
+		HttpRequest:    r,
+		HttpWriter:     w,
+	}
+
+	recorder := &apiRecorder{}
+	recorder.wg.Add(1)
+	go func() {
+		defer recorder.wg.Done()
+		for msg := range c.Send {
+			recorder.list = append(recorder.list, msg)
+		}
+	}()
+
+	return c, recorder
+}
+
+func apiResponse(ctx *Context, action string, msgList [][]byte) (*ApiData, int) {
+	var res *Action
+	for _, msg := range msgList {
+		var a Action
+		if json.Unmarshal(msg, &a) == nil && a.Action == action {
+			res = &a
+		}
+	}
+
+	if res == nil && ctx != nil && ctx.Response != nil {
+		res = ctx.Response
+	}
+
+	if res == nil {
+		if ctx == nil && len(msgList) > 0 {
+			//未进入路由（如被禁言），返回系统消息
+			var a Action
+			if json.Unmarshal(msgList[len(msgList)-1], &a) == nil {
+				res = &a
+			}
+		}
+
+		if res == nil {
+			return &ApiData{}, http.StatusOK
+		}
+	}
+
+	data := &ApiData{Code: res.Code, Msg: res.Msg, Data: res.Data}
+	if ctx != nil && ctx.httpStatus > 0 {
+		return data, ctx.httpStatus
+	}
+
+	return data, apiHttpStatus(res.Code)
+}
+
+// apiHttpStatus 根据状态码获取默认的HTTP状态码
+func apiHttpStatus(code int) int {
+	switch {
+	case code == 0:
+		return http.StatusOK
+	case code == -1001:
+		return http.StatusForbidden
+	case code == -1003:
+		return http.StatusTooManyRequests
+	case code == -1005:
+		return http.StatusNotFound
+	case code < 0:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func writeApiData(w http.ResponseWriter, status int, data *ApiData) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// apiRecorder 记录请求期间发送给临时客户端的消息
+type apiRecorder struct {
+	wg   sync.WaitGroup
+	list [][]byte
+}
+
+// close 结束临时客户端，返回全部消息
+func (rec *apiRecorder) close(c *Client) [][]byte {
+	if c.User != nil {
+		_ = c.User.appLogout(c.AppId, c)
+	}
+
+	c.Close()
+	rec.wg.Wait()
+	return rec.list
+}
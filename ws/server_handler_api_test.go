@@ -0,0 +1,123 @@
+package ws
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestApiHandler(t *testing.T) {
+	NewServer(http.NewServeMux())
+
+	router := NewRouter().Use(func(a *Context) {
+		if a.Client.HttpRequest.Header.Get("X-Token") != "secret" {
+			a.SendError((&Error{Code: 1120, Msg: "Please log in first"}).WithHttpStatus(http.StatusUnauthorized))
+			a.Abort()
+			return
+		}
+
+		a.Next()
+	})
+
+	router.Add("api.echo", func(a *Context) {
+		a.Send(H{"name": a.Get("name")})
+	})
+
+	router.Add("api.fail", func(a *Context) {
+		a.SendCode(4001, "name is required")
+	})
+
+	body, status := postApi(t, "/api/api.echo", "secret", `{"name":"aqi"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(0), gjson.Get(body, "code").Int())
+	require.Equal(t, "aqi", gjson.Get(body, "data.name").String())
+	require.Equal(t, int64(http.StatusOK), gjson.Get(body, "HttpStatus").Int())
+
+	body, status = postApi(t, "/api/api.echo", "", `{"name":"aqi"}`)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, int64(1120), gjson.Get(body, "code").Int())
+
+	body, status = postApi(t, "/api/api.fail", "secret", "")
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "name is required", gjson.Get(body, "msg").String())
+
+	_, status = postApi(t, "/api/api.missing", "secret", "{}")
+	require.Equal(t, http.StatusNotFound, status)
+
+	_, status = postApi(t, "/api/api.echo", "secret", "{bad")
+	require.Equal(t, http.StatusBadRequest, status)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/api.echo", nil)
+	recorder := httptest.NewRecorder()
+	ApiHandler(recorder, request)
+	require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	// 请求结束后异步发送的消息直接丢弃
+	late := make(chan struct{})
+	router.Add("api.late", func(a *Context) {
+		a.SendOk()
+		go func() {
+			defer close(late)
+			time.Sleep(10 * time.Millisecond)
+			a.Send(H{"late": true})
+		}()
+	})
+
+	_, status = postApi(t, "/api/api.late", "secret", "{}")
+	require.Equal(t, http.StatusOK, status)
+	<-late
+}
+
+func postApi(t *testing.T, path, token, body string) (string, int) {
+	t.Helper()
+
+	request := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("X-Token", token)
+	}
+
+	recorder := httptest.NewRecorder()
+	ApiHandler(recorder, request)
+
+	return recorder.Body.String(), recorder.Code
+}
+
+func TestServerCall(t *testing.T) {
+	s := NewInstance(nil)
+	defer s.Close()
+
+	router := s.Router()
+	require.Same(t, s, router.Server())
+
+	router.Group("call").Add("echo", func(a *Context) {
+		a.Send(H{"name": a.Get("name"), "token": a.Client.HttpRequest.Header.Get("X-Token")})
+	})
+	router.Group("call").Add("header", func(a *Context) {
+		a.Client.HttpWriter.Header().Set("X-Trace", "1")
+		a.SendOk()
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	request.Header.Set("X-Token", "secret")
+
+	res := s.Call(request, "call.echo", `{"name":"aqi"}`)
+	require.Equal(t, 0, res.Code)
+	require.Equal(t, http.StatusOK, res.HttpStatus)
+	require.Equal(t, map[string]any{"name": "aqi", "token": "secret"}, res.Data)
+
+	//处理函数可以照常使用 HttpWriter
+	res = s.Call(request, "call.header", "")
+	require.Equal(t, 0, res.Code)
+
+	res = s.Call(request, "call.missing", "")
+	require.Equal(t, -1005, res.Code)
+	require.Equal(t, http.StatusNotFound, res.HttpStatus)
+
+	require.Equal(t, []string{"call.echo", "call.header", "sys.setStatus"}, s.Manager().Names())
+}
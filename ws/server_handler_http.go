@@ -5,7 +5,6 @@ import (
 	"golang.org/x/time/rate"
 	"net"
 	"net/http"
-	"time"
 
 	"github.com/gobwas/ws"
 	"go.uber.org/zap"
@@ -14,7 +13,12 @@ import (
 	"github.com/wonli/aqi/utils/ip"
 )
 
-func HttpHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) HttpHandler(w http.ResponseWriter, r *http.Request) {
+	release, ok := s.admit(w, r)
+	if !ok {
+		return
+	}
+
 	u := ws.HTTPUpgrader{
 		Protocol: func(s string) bool {
 			return true
@@ -23,6 +27,7 @@ func HttpHandler(w http.ResponseWriter, r *http.Request) {
 
 	conn, _, h, err := u.Upgrade(r, w)
 	if err != nil {
+		release()
 		logger.SugarLog.Error("UpgradeHTTP",
 			zap.String("error", err.Error()),
 		)
@@ -36,24 +41,36 @@ func HttpHandler(w http.ResponseWriter, r *http.Request) {
 	ipAddr := ip.GetIPAddress(r)
 	addr, ok := conn.RemoteAddr().(*net.TCPAddr)
 	if !ok {
+		release()
+		_ = conn.Close()
 		logger.SugarLog.Errorf("获取IP地址错误")
 		return
 	}
 
 	c := &Client{
-		Hub:            Hub,
+		Hub:            s.hub,
 		Conn:           conn,
+		Transport:      TransportWebsocket,
 		Send:           make(chan []byte, 32),
 		RequestQueue:   make(chan string, 128),
 		Limiter:        rate.NewLimiter(50, 100),
 		IpAddress:      ipAddr,
 		IpAddressPort:  fmt.Sprintf("%s:%d", ipAddr, addr.Port),
-		ConnectionTime: time.Now(),
+		ConnectionTime: s.hub.now(),
 		HttpRequest:    r,
 		HttpWriter:     w,
+		release:        release,
+		heartbeat:      s.heartbeatPolicy(),
+	}
+
+	c.lastSeen.Store(c.ConnectionTime.UnixNano())
+	if err := c.Hub.connect(c); err != nil {
+		release()
+		_ = conn.Close()
+		logger.SugarLog.Error("Connect", zap.String("error", err.Error()))
+		return
 	}
 
-	c.Hub.Connection <- c
 	go c.Reader()
 	go c.Write()
 
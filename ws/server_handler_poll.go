@@ -0,0 +1,127 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	pollTimeout    = 25 * time.Second //单次轮询最长等待时间
+	pollSessionTTL = time.Minute      //超过该时间没有轮询时断开客户端
+)
+
+type pollResult struct {
+	Session  string            `json:"session"`
+	Messages []json.RawMessage `json:"messages"`
+}
+
+// PollHandler 长轮询，用于无法使用websocket和SSE的网络环境
+//
+// 不带会话ID的 GET 请求创建会话并立即返回会话ID；
+// 带会话ID的 GET 请求等待消息，有消息或超时后返回 {"session":"...","messages":[...]}；
+// POST 提交action，与 SSEHandler 相同
+func (s *Server) PollHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		s.SessionHandler(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	res := pollResult{Messages: []json.RawMessage{}}
+	c := s.sessionClient(r)
+	if c == nil {
+		release, ok := s.admit(w, r)
+		if !ok {
+			return
+		}
+
+		var err error
+		c, err = s.newSessionClient(r, TransportLongPolling, 256, release)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		c.LastHeartbeatTime = c.ConnectionTime
+
+		res.Session = c.SessionId
+		writePollResult(w, http.StatusOK, res)
+		return
+	}
+
+	res.Session = c.SessionId
+	c.LastHeartbeatTime = c.Hub.now()
+	c.lastSeen.Store(c.LastHeartbeatTime.UnixNano())
+	if c.User != nil {
+		c.User.LastHeartbeatTime = c.LastHeartbeatTime
+	}
+
+	timer := time.NewTimer(pollTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-r.Context().Done():
+		return
+	case <-timer.C:
+	case msg, ok := <-c.Send:
+		if !ok {
+			writePollResult(w, http.StatusGone, res)
+			return
+		}
+
+		res.Messages = append(res.Messages, msg)
+
+		//取出已排队的消息一并返回
+	drain:
+		for {
+			select {
+			case msg, ok = <-c.Send:
+				if !ok {
+					break drain
+				}
+
+				res.Messages = append(res.Messages, msg)
+			default:
+				break drain
+			}
+		}
+	}
+
+	for _, msg := range res.Messages {
+		c.Log("->", string(msg))
+	}
+
+	writePollResult(w, http.StatusOK, res)
+	for _, msg := range res.Messages {
+		c.sent(msg)
+	}
+
+	if c.Disconnecting.Load() {
+		c.Hub.disconnect(c)
+	}
+}
+
+func writePollResult(w http.ResponseWriter, status int, res pollResult) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// reapSessions 断开长时间未轮询的客户端
+func (h *Hubc) reapSessions() {
+	h.sessions.Range(func(key, value any) bool {
+		c := value.(*Client)
+		if c.Transport == TransportLongPolling && h.now().Sub(time.Unix(0, c.lastSeen.Load())) > pollSessionTTL {
+			h.disconnect(c)
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,61 @@
+package ws
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+)
+
+// SessionHandler 接收SSE和长轮询客户端提交的action
+//
+// 请求需携带 X-Session-Id 请求头或 session 参数，请求体与websocket消息相同:
+//
+//	{"id":"1","action":"hi","params":"{}"}
+//
+// 处理结果通过事件流或轮询返回
+func (s *Server) SessionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeApiData(w, http.StatusMethodNotAllowed, &ApiData{Code: -1005, Msg: "method not allowed"})
+		return
+	}
+
+	c := s.sessionClient(r)
+	if c == nil || c.IsClosed() {
+		writeApiData(w, http.StatusNotFound, &ApiData{Code: -1006, Msg: "session not found"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, apiMaxBodySize))
+	if err != nil {
+		writeApiData(w, http.StatusRequestEntityTooLarge, &ApiData{Code: ErrParamsInvalid.Code, Msg: err.Error()})
+		return
+	}
+
+	req := string(body)
+	if !gjson.Valid(req) {
+		writeApiData(w, http.StatusBadRequest, &ApiData{Code: ErrParamsInvalid.Code, Msg: ErrParamsInvalid.Msg})
+		return
+	}
+
+	c.Log("<-", req)
+	if !c.Limiter.Allow() {
+		c.Log("!!", "Too many requests, please retry later")
+		c.SendActionMsg(&Action{
+			Action: "sys.rateLimit",
+			Code:   -1003,
+			Msg:    "too many requests, please retry later",
+		})
+
+		writeApiData(w, http.StatusTooManyRequests, &ApiData{Code: -1003, Msg: "too many requests, please retry later"})
+		return
+	}
+
+	c.dispatchMu.Lock()
+	c.HttpRequest = r
+	Dispatcher(c, req)
+	c.dispatchMu.Unlock()
+
+	writeApiData(w, http.StatusAccepted, &ApiData{})
+}
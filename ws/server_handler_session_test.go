@@ -0,0 +1,168 @@
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestSSEHandler(t *testing.T) {
+	NewServer(http.NewServeMux())
+	NewRouter().Add("sse.echo", func(a *Context) {
+		a.Send(H{"name": a.Get("name")})
+	})
+
+	svr := httptest.NewServer(http.HandlerFunc(SSEHandler))
+	defer svr.Close()
+
+	res, err := http.Get(svr.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(res.Body)
+	event, data := readSSEEvent(t, reader)
+	require.Equal(t, "session", event)
+
+	session := gjson.Get(data, "session").String()
+	require.NotEmpty(t, session)
+
+	status := postSession(t, svr.URL, session, `{"id":"1","action":"sse.echo","params":"{\"name\":\"aqi\"}"}`)
+	require.Equal(t, http.StatusAccepted, status)
+
+	event, data = readSSEEvent(t, reader)
+	require.Equal(t, "message", event)
+	require.Equal(t, "sse.echo", gjson.Get(data, "action").String())
+	require.Equal(t, "1", gjson.Get(data, "id").String())
+	require.Equal(t, "aqi", gjson.Get(data, "data.name").String())
+
+	status = postSession(t, svr.URL, "missing", `{"action":"sse.echo"}`)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestPollHandler(t *testing.T) {
+	NewServer(http.NewServeMux())
+	NewRouter().Add("poll.echo", func(a *Context) {
+		a.Send(H{"name": a.Get("name")})
+	})
+
+	svr := httptest.NewServer(http.HandlerFunc(PollHandler))
+	defer svr.Close()
+
+	res := poll(t, svr.URL)
+	require.NotEmpty(t, res.Session)
+	require.Empty(t, res.Messages)
+
+	status := postSession(t, svr.URL, res.Session, `{"id":"2","action":"poll.echo","params":"{\"name\":\"aqi\"}"}`)
+	require.Equal(t, http.StatusAccepted, status)
+
+	res = poll(t, svr.URL+"?session="+res.Session)
+	require.Len(t, res.Messages, 1)
+	require.Equal(t, "poll.echo", gjson.GetBytes(res.Messages[0], "action").String())
+	require.Equal(t, "aqi", gjson.GetBytes(res.Messages[0], "data.name").String())
+}
+
+func readSSEEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	t.Helper()
+
+	var event, data string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if event != "" {
+				return event, data
+			}
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func postSession(t *testing.T, url, session, body string) int {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	request.Header.Set("X-Session-Id", session)
+
+	res, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	return res.StatusCode
+}
+
+func poll(t *testing.T, url string) pollResult {
+	t.Helper()
+
+	res, err := http.Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var result pollResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	return result
+}
+
+func TestReapSessionsAfterStop(t *testing.T) {
+	s := NewInstance(nil)
+	h := s.Hub()
+
+	c := &Client{Hub: h, Transport: TransportLongPolling, SessionId: "stale", Send: make(chan []byte, 1)}
+	h.sessions.Store(c.SessionId, c)
+	s.Close()
+
+	done := make(chan struct{})
+	go func() {
+		h.reapSessions()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reapSessions blocked after Hub.Stop")
+	}
+}
+
+func TestSessionHandlersAfterStop(t *testing.T) {
+	s := NewInstance(nil)
+	s.Close()
+
+	for name, handler := range map[string]http.HandlerFunc{"sse": s.SSEHandler, "poll": s.PollHandler} {
+		w := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			handler(w, httptest.NewRequest(http.MethodGet, "/"+name, nil))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("%s handler blocked after Hub.Stop", name)
+		}
+
+		require.Equal(t, http.StatusServiceUnavailable, w.Code, name)
+	}
+
+	s.Hub().sessions.Range(func(key, value any) bool {
+		t.Fatalf("session %v kept after Hub.Stop", key)
+		return true
+	})
+}
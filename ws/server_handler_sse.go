@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SSE保活注释发送间隔
+const sseKeepalive = 15 * time.Second
+
+// SSEHandler 通过 Server-Sent Events 推送消息，用于无法使用websocket的网络环境
+//
+// GET 建立事件流，首个 session 事件返回会话ID，之后每条消息为一个 message 事件；
+// POST 提交action，需携带 X-Session-Id 请求头或 session 参数，请求体与websocket消息相同
+func (s *Server) SSEHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		s.SessionHandler(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	release, ok := s.admit(w, r)
+	if !ok {
+		return
+	}
+
+	c, err := s.newSessionClient(r, TransportSSE, 32, release)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	c.HttpWriter = w
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	timer := time.NewTicker(sseKeepalive)
+	defer func() {
+		timer.Stop()
+		c.Hub.disconnect(c)
+	}()
+
+	var eventId int
+	_, err = fmt.Fprintf(w, "event: session\ndata: {\"session\":%q}\n\n", c.SessionId)
+	if err != nil {
+		return
+	}
+
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-c.Send:
+			if !ok {
+				return
+			}
+
+			eventId++
+			_, err = fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", eventId, msg)
+			if err != nil {
+				c.Log("xx", "Send msg error", err.Error())
+				return
+			}
+
+			flusher.Flush()
+
+			//如果设置为断开状态
+			//在消息发送完成后将断开与服务器的连接
+			if c.sent(msg) {
+				return
+			}
+
+			c.Log("->", string(msg))
+		case <-timer.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				c.Log("xx", "Error actively pinging the client", err.Error())
+				return
+			}
+
+			flusher.Flush()
+			c.LastHeartbeatTime = c.Hub.now()
+			if c.User != nil {
+				c.User.LastHeartbeatTime = c.LastHeartbeatTime
+			}
+		}
+	}
+}
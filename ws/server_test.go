@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestIndependentServers(t *testing.T) {
+	app := NewInstance(http.NewServeMux())
+	defer app.Close()
+
+	admin := NewInstance(http.NewServeMux())
+	defer admin.Close()
+
+	app.Router().Add("server.whoami", func(a *Context) {
+		require.Same(t, app, a.Server)
+		a.Send(H{"name": "app"})
+	})
+
+	admin.Router().Add("server.whoami", func(a *Context) {
+		require.Same(t, admin, a.Server)
+		a.Send(H{"name": "admin"})
+	})
+
+	require.NotSame(t, app.Hub(), admin.Hub())
+	require.False(t, InitManager().Has("server.whoami"))
+
+	call := func(s *Server) string {
+		request := httptest.NewRequest(http.MethodPost, "/api/server.whoami", bytes.NewBufferString("{}"))
+		recorder := httptest.NewRecorder()
+		s.ApiHandler(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return gjson.Get(recorder.Body.String(), "data.name").String()
+	}
+
+	require.Equal(t, "app", call(app))
+	require.Equal(t, "admin", call(admin))
+
+	client := &Client{Hub: app.Hub(), Send: make(chan []byte, 8)}
+	require.NoError(t, app.Hub().UserLogin("u1", "app", client))
+	require.NotNil(t, app.Hub().User("u1"))
+	require.Nil(t, admin.Hub().User("u1"))
+}
+
+func TestNewHubcKeepsDefaultHub(t *testing.T) {
+	old := Default().Hub()
+
+	h := NewHubc()
+	go h.Run()
+	defer h.Stop()
+
+	require.NotSame(t, old, h)
+	require.Same(t, old, Default().Hub())
+	require.Same(t, old, Hub)
+
+	//默认 Hubc 未被停止
+	select {
+	case <-old.done:
+		t.Fatal("default hub stopped by NewHubc")
+	default:
+	}
+}
@@ -11,6 +11,7 @@ type User struct {
 	//公共基础信息
 	Uid          uint             `json:"uid"`                //整型唯一ID
 	Suid         string           `json:"suid"`               //字符唯一ID
+	TenantId     uint             `json:"tenantId,omitempty"` //所属租户
 	GroupId      string           `json:"groupId"`            //分组ID
 	SuperAdmin   bool             `json:"superAdmin"`         //是否超管
 	RoleId       []uint           `json:"roleId,omitempty"`   //用户角色
@@ -23,6 +24,10 @@ type User struct {
 
 	//禁言时间
 	Ban *time.Time `json:"ban,omitempty"`
+	ban *Ban
+
+	//自动设置为离开状态，收到请求后恢复在线
+	autoAway bool
 
 	//最后心跳时间
 	LastHeartbeatTime time.Time
@@ -90,31 +95,52 @@ func (u *User) UnsubAllTopics() int {
 	return len(u.SubTopics)
 }
 
-// AppLogin 用户APP客户端登录
+// AppLogin 用户APP客户端登录，按 SessionPolicy 下线冲突的连接
 func (u *User) appLogin(appId string, client *Client) error {
-	var index int
-	var appClient *Client
-	for i, app := range u.AppClients {
-		if app.AppId == appId {
-			index = i
-			appClient = app
-			break
+	var policy SessionPolicy
+	s := u.server()
+	if s != nil {
+		policy = s.sessionPolicy(appId)
+	}
+
+	u.Lock()
+	first := len(u.AppClients) == 0
+	kicked := policy.conflicts(u.AppClients, appId, client)
+	if len(kicked) > 0 && policy.RejectNew {
+		u.Unlock()
+		return ErrSessionExists
+	}
+
+	//登录客户端数限制，与加入客户端列表在同一把锁内完成
+	if s != nil {
+		if err := s.admitUser(u.AppClients, kicked, client); err != nil {
+			u.Unlock()
+			return err
 		}
 	}
 
+	u.AppClients = slices.DeleteFunc(u.AppClients, func(app *Client) bool {
+		return app == client || slices.Contains(kicked, app)
+	})
+	u.AppClients = append(u.AppClients, client)
+	u.Unlock()
+
 	client.User = u
 	client.AppId = appId
 	client.IsLogin = true
-	if appClient != nil {
-		if appClient.Conn != client.Conn {
-			u.AppClients = slices.Delete(u.AppClients, index, index+1)
-			u.AppClients = append(u.AppClients, client)
 
-			//已登录连接下线
-			u.Hub.Disconnect <- appClient
+	//已登录连接下线
+	now := u.Hub.now()
+	for _, c := range kicked {
+		c.kick(client, now)
+	}
+
+	//上线通知
+	if first {
+		u.indexLocation()
+		if s := u.server(); s != nil {
+			s.publishPresence(u)
 		}
-	} else {
-		u.AppClients = append(u.AppClients, client)
 	}
 
 	u.Hub.PubSub.Pub("login", u)
@@ -123,21 +149,24 @@ func (u *User) appLogin(appId string, client *Client) error {
 
 // app退出
 func (u *User) appLogout(appId string, logoutClient *Client) error {
-	removeIndex := -1
-	for appIndex, appClient := range u.AppClients {
-		if appClient.AppId == appId && logoutClient.Conn == appClient.Conn {
-			removeIndex = appIndex
-			break
+	u.Lock()
+	count := len(u.AppClients)
+	u.AppClients = slices.DeleteFunc(u.AppClients, func(appClient *Client) bool {
+		return appClient == logoutClient
+	})
+	offline := count > 0 && len(u.AppClients) == 0
+	u.Unlock()
+
+	//下线通知
+	if offline {
+		u.indexLocation()
+		if s := u.server(); s != nil {
+			s.publishPresence(u)
 		}
 	}
 
-	if removeIndex > -1 {
-		//从客户端中移除
-		u.AppClients = slices.Delete(u.AppClients, removeIndex, removeIndex+1)
-
-		//关闭客户端
-		logoutClient.Close()
-	}
+	//关闭客户端，被踢下线的连接已不在列表中
+	logoutClient.Close()
 
 	u.Hub.PubSub.Pub("logout", u)
 	return nil
@@ -145,7 +174,7 @@ func (u *User) appLogout(appId string, logoutClient *Client) error {
 
 // AppClient 获取APP客户端
 func (u *User) AppClient(appId string) *Client {
-	for _, app := range u.AppClients {
+	for _, app := range u.Clients() {
 		cc := app
 		if cc.AppId == appId {
 			return cc
@@ -155,35 +184,98 @@ func (u *User) AppClient(appId string) *Client {
 	return nil
 }
 
-// IsBanned 是否被封禁
+// IsBanned 是否被封禁，返回解封时间，永久封禁时为 nil
 func (u *User) IsBanned() (bool, *time.Time) {
-	if u.Ban == nil || u.Ban.IsZero() {
+	ban := u.ActiveBan()
+	if ban == nil {
 		return false, nil
 	}
 
-	return true, u.Ban
+	return true, ban.ExpiresAt
+}
+
+// ActiveBan 当前生效的封禁记录，过期后自动解除
+func (u *User) ActiveBan() *Ban {
+	u.RLock()
+	ban := u.ban
+	u.RUnlock()
+
+	if ban == nil {
+		return nil
+	}
+
+	if ban.Expired(u.Hub.now()) {
+		u.Lock()
+		if u.ban == ban {
+			u.ban = nil
+			u.Ban = nil
+		}
+		u.Unlock()
+		return nil
+	}
+
+	return ban
 }
 
-// Banned 禁言用户
+// Banned 禁言用户，通过 Server 保存到封禁存储
 func (u *User) Banned(t time.Duration) *time.Time {
-	banTime := time.Now().Add(t)
-	u.Ban = &banTime
+	if s := u.server(); s != nil {
+		ban, err := s.TenantBan(u.TenantId, u.Suid, t, "")
+		if err == nil {
+			u.setBan(ban)
+			return ban.ExpiresAt
+		}
+	}
+
+	banTime := u.Hub.now().Add(t)
+	u.setBan(&Ban{Uid: u.Suid, TenantId: u.TenantId, CreatedAt: u.Hub.now(), ExpiresAt: &banTime})
 	return u.Ban
 }
 
 // Unban 禁言解除
 func (u *User) Unban() *time.Time {
+	if s := u.server(); s != nil {
+		_ = s.TenantUnban(u.TenantId, u.Suid)
+	}
+
+	u.setBan(nil)
+	return nil
+}
+
+func (u *User) setBan(ban *Ban) {
+	u.Lock()
+	defer u.Unlock()
+
+	u.ban = ban
 	u.Ban = nil
-	return u.Ban
+	if ban != nil {
+		u.Ban = ban.ExpiresAt
+	}
+}
+
+func (u *User) server() *Server {
+	if u.Hub == nil {
+		return nil
+	}
+
+	return u.Hub.server
 }
 
 // IsOnline 用户是否在线
 func (u *User) IsOnline() bool {
-	if u == nil || u.AppClients == nil {
+	if u == nil {
 		return false
 	}
 
-	return len(u.AppClients) > 0
+	return len(u.Clients()) > 0
+}
+
+// Clients 已登录的客户端列表快照
+func (u *User) Clients() []*Client {
+	u.RLock()
+	defer u.RUnlock()
+
+	return slices.Clone(u.AppClients)
 }
 
 // SendMsg 发送消息
@@ -192,7 +284,7 @@ func (u *User) SendMsg(msg []byte) {
 		return
 	}
 
-	for _, client := range u.AppClients {
+	for _, client := range u.Clients() {
 		client.SendMsg(msg)
 	}
 }
@@ -0,0 +1,199 @@
+package ws
+
+import (
+	"path"
+	"sync"
+	"time"
+
+	"github.com/wonli/aqi/logger"
+)
+
+// Ban 用户封禁记录
+type Ban struct {
+	Uid       string     `json:"uid"`
+	TenantId  uint       `json:"tenantId,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	Actions   []string   `json:"actions,omitempty"`   //受限的action，支持通配符如 chat.*，为空时限制全部
+	CreatedAt time.Time  `json:"createdAt"`           //封禁时间
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` //解封时间，为空时永久封禁
+}
+
+// Expired 是否已过期
+func (b *Ban) Expired(now time.Time) bool {
+	return b.ExpiresAt != nil && !now.Before(*b.ExpiresAt)
+}
+
+// Match action 是否在封禁范围内
+func (b *Ban) Match(action string) bool {
+	if len(b.Actions) == 0 {
+		return true
+	}
+
+	for _, pattern := range b.Actions {
+		ok, err := path.Match(pattern, action)
+		if err == nil && ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BanStore 封禁记录存储，用户离线被清理或进程重启后封禁依然有效
+type BanStore interface {
+	// Get 读取租户内用户的封禁记录，不存在时返回 nil
+	Get(tenantId uint, uid string) (*Ban, error)
+
+	// Set 保存封禁记录，已存在时覆盖
+	Set(ban *Ban) error
+
+	// Delete 删除封禁记录
+	Delete(tenantId uint, uid string) error
+}
+
+// MemoryBanStore 内存封禁存储，Server 默认使用
+type MemoryBanStore struct {
+	mu   sync.RWMutex
+	bans map[any]*Ban
+}
+
+func NewMemoryBanStore() *MemoryBanStore {
+	return &MemoryBanStore{bans: map[any]*Ban{}}
+}
+
+func (m *MemoryBanStore) Get(tenantId uint, uid string) (*Ban, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.bans[scopedKey(tenantId, uid)], nil
+}
+
+func (m *MemoryBanStore) Set(ban *Ban) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.bans[scopedKey(ban.TenantId, ban.Uid)] = ban
+	return nil
+}
+
+func (m *MemoryBanStore) Delete(tenantId uint, uid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.bans, scopedKey(tenantId, uid))
+	return nil
+}
+
+// SetBanStore 设置封禁存储，默认保存在内存中
+func (s *Server) SetBanStore(store BanStore) {
+	s.banMu.Lock()
+	defer s.banMu.Unlock()
+
+	s.bans = store
+}
+
+func (s *Server) banStore() BanStore {
+	s.banMu.Lock()
+	defer s.banMu.Unlock()
+
+	if s.bans == nil {
+		s.bans = NewMemoryBanStore()
+	}
+
+	return s.bans
+}
+
+// Ban 封禁用户，d 不大于0时永久封禁，actions 为空时限制全部请求
+//
+// 用户在线时推送 sys.ban 通知
+func (s *Server) Ban(uid string, d time.Duration, reason string, actions ...string) (*Ban, error) {
+	return s.TenantBan(0, uid, d, reason, actions...)
+}
+
+// TenantBan 封禁租户内的用户
+func (s *Server) TenantBan(tenantId uint, uid string, d time.Duration, reason string, actions ...string) (*Ban, error) {
+	now := s.hub.now()
+	ban := &Ban{
+		Uid:       uid,
+		TenantId:  tenantId,
+		Reason:    reason,
+		Actions:   actions,
+		CreatedAt: now,
+	}
+
+	if d > 0 {
+		expiresAt := now.Add(d)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	err := s.banStore().Set(ban)
+	if err != nil {
+		return nil, err
+	}
+
+	user := s.hub.TenantUser(tenantId, uid)
+	if user != nil {
+		user.setBan(ban)
+		for _, c := range user.Clients() {
+			c.SendMsg(banAction(ban))
+		}
+	}
+
+	return ban, nil
+}
+
+// Unban 解除封禁
+func (s *Server) Unban(uid string) error {
+	return s.TenantUnban(0, uid)
+}
+
+// TenantUnban 解除租户内用户的封禁
+func (s *Server) TenantUnban(tenantId uint, uid string) error {
+	err := s.banStore().Delete(tenantId, uid)
+	if err != nil {
+		return err
+	}
+
+	user := s.hub.TenantUser(tenantId, uid)
+	if user != nil {
+		user.setBan(nil)
+	}
+
+	return nil
+}
+
+// BanOf 查询用户当前的封禁记录，未封禁或已过期时返回 nil
+func (s *Server) BanOf(uid string) (*Ban, error) {
+	return s.TenantBanOf(0, uid)
+}
+
+// TenantBanOf 查询租户内用户当前的封禁记录
+func (s *Server) TenantBanOf(tenantId uint, uid string) (*Ban, error) {
+	store := s.banStore()
+	ban, err := store.Get(tenantId, uid)
+	if err != nil || ban == nil {
+		return nil, err
+	}
+
+	if ban.Expired(s.hub.now()) {
+		return nil, store.Delete(tenantId, uid)
+	}
+
+	return ban, nil
+}
+
+// loadBan 登录时从存储中恢复封禁状态
+func (s *Server) loadBan(user *User) {
+	ban, err := s.TenantBanOf(user.TenantId, user.Suid)
+	if err != nil {
+		logger.SugarLog.Errorf("Failed to load ban of %s: %s", user.Suid, err.Error())
+		return
+	}
+
+	user.setBan(ban)
+}
+
+// banAction sys.ban 通知，data 为封禁记录
+func banAction(ban *Ban) []byte {
+	return (&Action{Action: "sys.ban", Code: -1001, Msg: ban.Reason, Data: ban}).Encode()
+}
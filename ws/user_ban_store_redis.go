@@ -0,0 +1,76 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBanStore 将封禁记录保存到redis，过期时间与解封时间一致，由redis自动清理
+type RedisBanStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisBanStore client 一般来自 store.Redis(key).Use()，prefix 为空时使用 aqi:ban:
+func NewRedisBanStore(client redis.UniversalClient, prefix string) *RedisBanStore {
+	if prefix == "" {
+		prefix = "aqi:ban:"
+	}
+
+	return &RedisBanStore{client: client, prefix: prefix}
+}
+
+// key 租户0的用户为 prefix+uid，其它租户为 prefix+tenantId:uid
+func (s *RedisBanStore) key(tenantId uint, uid string) string {
+	if tenantId == 0 {
+		return s.prefix + uid
+	}
+
+	return s.prefix + strconv.FormatUint(uint64(tenantId), 10) + ":" + uid
+}
+
+func (s *RedisBanStore) Get(tenantId uint, uid string) (*Ban, error) {
+	data, err := s.client.Get(context.Background(), s.key(tenantId, uid)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ban Ban
+	err = json.Unmarshal(data, &ban)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
+func (s *RedisBanStore) Set(ban *Ban) error {
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+
+	//按剩余时间设置有效期，已过期的记录直接删除
+	var ttl time.Duration
+	if ban.ExpiresAt != nil {
+		ttl = time.Until(*ban.ExpiresAt)
+		if ttl <= 0 {
+			return s.Delete(ban.TenantId, ban.Uid)
+		}
+	}
+
+	return s.client.Set(context.Background(), s.key(ban.TenantId, ban.Uid), data, ttl).Err()
+}
+
+func (s *RedisBanStore) Delete(tenantId uint, uid string) error {
+	return s.client.Del(context.Background(), s.key(tenantId, uid)).Err()
+}
@@ -0,0 +1,71 @@
+package ws
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserBan 封禁数据表
+type UserBan struct {
+	TenantId  uint       `gorm:"primaryKey;autoIncrement:false" json:"tenantId"`
+	Uid       string     `gorm:"primaryKey;size:191" json:"uid"`
+	Reason    string     `gorm:"size:255" json:"reason"`
+	Actions   []string   `gorm:"serializer:json;type:text" json:"actions"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `gorm:"index" json:"expiresAt"`
+}
+
+// SQLBanStore 将封禁记录保存到数据库
+type SQLBanStore struct {
+	db *gorm.DB
+}
+
+// NewSQLBanStore db 一般来自 store.DB(key).Use()
+func NewSQLBanStore(db *gorm.DB) *SQLBanStore {
+	return &SQLBanStore{db: db}
+}
+
+// AutoMigrate 创建封禁数据表
+func (s *SQLBanStore) AutoMigrate() error {
+	return s.db.AutoMigrate(&UserBan{})
+}
+
+func (s *SQLBanStore) Get(tenantId uint, uid string) (*Ban, error) {
+	var rows []UserBan
+	err := s.db.Where("tenant_id = ? AND uid = ?", tenantId, uid).Limit(1).Find(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	row := rows[0]
+	return &Ban{
+		Uid:       row.Uid,
+		TenantId:  row.TenantId,
+		Reason:    row.Reason,
+		Actions:   row.Actions,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+	}, nil
+}
+
+func (s *SQLBanStore) Set(ban *Ban) error {
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&UserBan{
+		TenantId:  ban.TenantId,
+		Uid:       ban.Uid,
+		Reason:    ban.Reason,
+		Actions:   ban.Actions,
+		CreatedAt: ban.CreatedAt,
+		ExpiresAt: ban.ExpiresAt,
+	}).Error
+}
+
+func (s *SQLBanStore) Delete(tenantId uint, uid string) error {
+	return s.db.Where("tenant_id = ? AND uid = ?", tenantId, uid).Delete(&UserBan{}).Error
+}
+
+// DeleteExpired 清理已过期的封禁记录
+func (s *SQLBanStore) DeleteExpired(now time.Time) error {
+	return s.db.Where("expires_at IS NOT NULL AND expires_at <= ?", now).Delete(&UserBan{}).Error
+}
@@ -0,0 +1,211 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type banClock struct {
+	now atomic.Int64
+}
+
+func (c *banClock) Now() time.Time {
+	return time.Unix(0, c.now.Load())
+}
+
+func TestServerBan(t *testing.T) {
+	s := NewInstance(http.NewServeMux())
+	defer s.Close()
+
+	clock := &banClock{}
+	clock.now.Store(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	s.Hub().Clock = clock
+
+	s.Router().Add("chat.send", func(a *Context) { a.Send(H{"ok": true}) })
+	s.Router().Add("profile.get", func(a *Context) { a.Send(H{"ok": true}) })
+
+	next := func(c *Client) gjson.Result {
+		select {
+		case msg := <-c.Send:
+			return gjson.ParseBytes(msg)
+		case <-time.After(time.Second):
+			t.Fatal("no message")
+			return gjson.Result{}
+		}
+	}
+
+	alice := &Client{Hub: s.Hub(), Send: make(chan []byte, 8)}
+	require.NoError(t, s.Hub().UserLogin("alice", "web", alice))
+
+	ban, err := s.Ban("alice", 10*time.Minute, "spam", "chat.*")
+	require.NoError(t, err)
+	require.NotNil(t, ban.ExpiresAt)
+
+	res := next(alice)
+	require.Equal(t, "sys.ban", res.Get("action").String())
+	require.Equal(t, int64(-1001), res.Get("code").Int())
+	require.Equal(t, "spam", res.Get("data.reason").String())
+	require.Equal(t, `["chat.*"]`, res.Get("data.actions").Raw)
+	require.Equal(t, "2024-01-01T00:10:00Z", res.Get("data.expiresAt").String())
+	require.False(t, res.Get("ban").Exists())
+
+	Dispatcher(alice, `{"action":"chat.send"}`)
+	require.Equal(t, "sys.ban", next(alice).Get("action").String())
+
+	Dispatcher(alice, `{"action":"profile.get"}`)
+	require.Equal(t, "profile.get", next(alice).Get("action").String())
+
+	//用户被清理后重新登录，封禁依然有效
+	s.Hub().Users.Delete("alice")
+	alice = &Client{Hub: s.Hub(), Send: make(chan []byte, 8)}
+	require.NoError(t, s.Hub().UserLogin("alice", "web", alice))
+
+	Dispatcher(alice, `{"action":"chat.send"}`)
+	require.Equal(t, "sys.ban", next(alice).Get("action").String())
+
+	//到期自动解除
+	clock.now.Add(int64(11 * time.Minute))
+	Dispatcher(alice, `{"action":"chat.send"}`)
+	require.Equal(t, "chat.send", next(alice).Get("action").String())
+
+	ban, err = s.BanOf("alice")
+	require.NoError(t, err)
+	require.Nil(t, ban)
+
+	_, err = s.Ban("alice", 0, "")
+	require.NoError(t, err)
+	banned, until := alice.User.IsBanned()
+	require.True(t, banned)
+	require.Nil(t, until)
+
+	require.NoError(t, s.Unban("alice"))
+	banned, _ = alice.User.IsBanned()
+	require.False(t, banned)
+}
+
+func TestSQLBanStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	store := NewSQLBanStore(db)
+	require.NoError(t, store.AutoMigrate())
+
+	ban, err := store.Get(0, "bob")
+	require.NoError(t, err)
+	require.Nil(t, ban)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(time.Hour)
+	require.NoError(t, store.Set(&Ban{Uid: "bob", Reason: "spam", CreatedAt: now}))
+	require.NoError(t, store.Set(&Ban{Uid: "bob", Reason: "flood", Actions: []string{"chat.*"}, CreatedAt: now, ExpiresAt: &expiresAt}))
+
+	ban, err = store.Get(0, "bob")
+	require.NoError(t, err)
+	require.Equal(t, "flood", ban.Reason)
+	require.Equal(t, []string{"chat.*"}, ban.Actions)
+	require.True(t, expiresAt.Equal(*ban.ExpiresAt))
+
+	//同名用户在不同租户的封禁互不影响
+	require.NoError(t, store.Set(&Ban{Uid: "bob", TenantId: 2, Reason: "abuse", CreatedAt: now}))
+	ban, err = store.Get(2, "bob")
+	require.NoError(t, err)
+	require.Equal(t, "abuse", ban.Reason)
+	require.NoError(t, store.Delete(2, "bob"))
+	ban, err = store.Get(0, "bob")
+	require.NoError(t, err)
+	require.Equal(t, "flood", ban.Reason)
+
+	require.NoError(t, store.DeleteExpired(now.Add(2*time.Hour)))
+	ban, err = store.Get(0, "bob")
+	require.NoError(t, err)
+	require.Nil(t, ban)
+}
+
+// fakeRedis 只实现 RedisBanStore 用到的命令
+type fakeRedis struct {
+	redis.UniversalClient
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func (r *fakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	value, ok := r.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+
+	return redis.NewStringResult(value, nil)
+}
+
+func (r *fakeRedis) Set(ctx context.Context, key string, value any, ttl time.Duration) *redis.StatusCmd {
+	r.values[key] = string(value.([]byte))
+	r.ttls[key] = ttl
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (r *fakeRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	for _, key := range keys {
+		delete(r.values, key)
+		delete(r.ttls, key)
+	}
+
+	return redis.NewIntResult(int64(len(keys)), nil)
+}
+
+func TestRedisBanStore(t *testing.T) {
+	client := &fakeRedis{values: map[string]string{}, ttls: map[string]time.Duration{}}
+	store := NewRedisBanStore(client, "")
+
+	ban, err := store.Get(0, "bob")
+	require.NoError(t, err)
+	require.Nil(t, ban)
+
+	//有效期为剩余时间，不是完整的封禁时长
+	createdAt := time.Now().Add(-time.Hour)
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, store.Set(&Ban{Uid: "bob", Reason: "flood", Actions: []string{"chat.*"}, CreatedAt: createdAt, ExpiresAt: &expiresAt}))
+	require.LessOrEqual(t, client.ttls["aqi:ban:bob"], time.Hour)
+	require.Greater(t, client.ttls["aqi:ban:bob"], 59*time.Minute)
+
+	ban, err = store.Get(0, "bob")
+	require.NoError(t, err)
+	require.Equal(t, "flood", ban.Reason)
+	require.Equal(t, []string{"chat.*"}, ban.Actions)
+	require.True(t, expiresAt.Equal(*ban.ExpiresAt))
+
+	require.NoError(t, store.Set(&Ban{Uid: "bob", CreatedAt: createdAt}))
+	require.Equal(t, time.Duration(0), client.ttls["aqi:ban:bob"])
+
+	//已过期的记录直接删除
+	expired := time.Now().Add(-time.Minute)
+	require.NoError(t, store.Set(&Ban{Uid: "carol", CreatedAt: createdAt, ExpiresAt: &expired}))
+	require.NotContains(t, client.values, "aqi:ban:carol")
+
+	require.NoError(t, store.Set(&Ban{Uid: "bob", TenantId: 2, CreatedAt: createdAt}))
+	ban, err = store.Get(2, "bob")
+	require.NoError(t, err)
+	require.Equal(t, uint(2), ban.TenantId)
+	require.Contains(t, client.values, "aqi:ban:2:bob")
+
+	require.NoError(t, store.Delete(0, "bob"))
+	ban, err = store.Get(0, "bob")
+	require.NoError(t, err)
+	require.Nil(t, ban)
+}
+
+func TestBanMatch(t *testing.T) {
+	ban := &Ban{Actions: []string{"chat.*", "pub"}}
+	require.True(t, ban.Match("chat.send"))
+	require.True(t, ban.Match("pub"))
+	require.False(t, ban.Match("profile.get"))
+	require.True(t, (&Ban{}).Match("profile.get"))
+}
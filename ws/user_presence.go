@@ -0,0 +1,175 @@
+package ws
+
+import (
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// Presence 推送给关注者的在线状态
+type Presence struct {
+	Uid    string           `json:"uid"`
+	Status UserOnlineStatus `json:"status"`
+	Online bool             `json:"online"`
+	Time   time.Time        `json:"time"`
+}
+
+// PresenceResolver 返回可以看到 user 在线状态的用户uid，只推送给同一租户内在线的用户
+type PresenceResolver func(user *User) []string
+
+// PresencePolicy 在线状态策略
+type PresencePolicy struct {
+	AwayAfter time.Duration    //超过该时间没有请求时自动设为离开，0为不启用
+	Resolver  PresenceResolver //为空时使用 GroupPresence
+}
+
+// GroupPresence 同一 GroupId 的用户互相可见
+func GroupPresence(h *Hubc) PresenceResolver {
+	return func(user *User) []string {
+		if user.GroupId == "" {
+			return nil
+		}
+
+		var list []string
+		for _, u := range h.TenantUsers(user.TenantId) {
+			if u.GroupId == user.GroupId {
+				list = append(list, u.Suid)
+			}
+		}
+
+		return list
+	}
+}
+
+// SetPresence 设置在线状态策略
+func (s *Server) SetPresence(policy PresencePolicy) {
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+
+	s.presence = policy
+}
+
+func (s *Server) presencePolicy() PresencePolicy {
+	s.presenceMu.Lock()
+	defer s.presenceMu.Unlock()
+
+	policy := s.presence
+	if policy.Resolver == nil {
+		policy.Resolver = GroupPresence(s.hub)
+	}
+
+	return policy
+}
+
+// SetStatus 修改用户状态并推送给关注者
+func (s *Server) SetStatus(user *User, status UserOnlineStatus) bool {
+	if !IsValidStatus(status) {
+		return false
+	}
+
+	user.Lock()
+	changed := user.OnlineStatus != status
+	user.OnlineStatus = status
+	user.autoAway = false
+	user.Unlock()
+
+	if changed {
+		s.publishPresence(user)
+	}
+
+	return true
+}
+
+// publishPresence 推送在线状态给关注者并发布 presence 主题
+func (s *Server) publishPresence(user *User) {
+	user.RLock()
+	presence := &Presence{
+		Uid:    user.Suid,
+		Status: user.OnlineStatus,
+		Online: len(user.AppClients) > 0,
+		Time:   s.hub.now(),
+	}
+	user.RUnlock()
+
+	msg := (&Action{Action: "sys.presence", Data: presence}).Encode()
+	for _, uid := range s.presencePolicy().Resolver(user) {
+		if uid == user.Suid {
+			continue
+		}
+
+		watcher := s.hub.TenantUser(user.TenantId, uid)
+		if watcher.IsOnline() {
+			watcher.SendMsg(msg)
+		}
+	}
+
+	s.hub.PubSub.Tenant(user.TenantId).Pub("presence", presence)
+}
+
+// StatusHandler 处理 sys.setStatus，参数为 {"status":1}，需要登录
+//
+// NewInstance 默认注册该路由，需要中间件时可通过 Manager().Add 替换
+func StatusHandler(a *Context) {
+	c := a.Client
+	if !c.IsLogin || c.User == nil {
+		a.SendError(ErrUncertified)
+		return
+	}
+
+	status := gjson.Get(a.Params, "status")
+	if status.Type != gjson.Number || !a.Server.SetStatus(c.User, UserOnlineStatus(status.Uint())) {
+		a.SendError(ErrParamsInvalid)
+		return
+	}
+
+	a.Send(H{"status": c.User.OnlineStatus})
+}
+
+// touchPresence 收到请求时恢复自动离开的用户
+func (s *Server) touchPresence(user *User) {
+	user.Lock()
+	back := user.autoAway
+	if back {
+		user.autoAway = false
+		user.OnlineStatus = UserStatusOnline
+	}
+	user.Unlock()
+
+	if back {
+		s.publishPresence(user)
+	}
+}
+
+// sweepPresence 超过 AwayAfter 没有请求的在线用户设为离开
+func (s *Server) sweepPresence(user *User) {
+	awayAfter := s.presencePolicy().AwayAfter
+	if awayAfter <= 0 {
+		return
+	}
+
+	var last int64
+	for _, c := range user.Clients() {
+		t := c.lastRequest.Load()
+		if t == 0 {
+			t = c.ConnectionTime.UnixNano()
+		}
+
+		last = max(last, t)
+	}
+
+	if last == 0 || s.hub.now().Sub(time.Unix(0, last)) < awayAfter {
+		return
+	}
+
+	user.Lock()
+	away := user.OnlineStatus == UserStatusOnline
+	if away {
+		user.OnlineStatus = UserStatusLeaving
+		user.autoAway = true
+	}
+	user.Unlock()
+
+	if away {
+		s.publishPresence(user)
+	}
+}
@@ -0,0 +1,118 @@
+package ws
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestPresence(t *testing.T) {
+	s := NewInstance(http.NewServeMux())
+	defer s.Close()
+
+	clock := &banClock{}
+	clock.now.Store(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	s.Hub().Clock = clock
+	s.SetPresence(PresencePolicy{AwayAfter: 5 * time.Minute})
+
+	//读取指定action的消息，跳过其它消息
+	next := func(c *Client, action string) gjson.Result {
+		for {
+			select {
+			case msg := <-c.Send:
+				res := gjson.ParseBytes(msg)
+				if res.Get("action").String() == action {
+					return res
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("no %s message", action)
+				return gjson.Result{}
+			}
+		}
+	}
+
+	login := func(uid string) *Client {
+		c := &Client{Hub: s.Hub(), Send: make(chan []byte, 16)}
+		require.NoError(t, s.Hub().UserLogin(uid, "web", c))
+		c.User.GroupId = "team"
+		return c
+	}
+
+	alice := login("alice")
+
+	//用户资料在登录前已加载，上线时即可通知同组用户
+	user := NewUser("bob")
+	user.Hub = s.Hub()
+	user.GroupId = "team"
+	s.Hub().Users.Store("bob", user)
+	bob := login("bob")
+	carol := &Client{Hub: s.Hub(), Send: make(chan []byte, 16)}
+	require.NoError(t, s.Hub().UserLogin("carol", "web", carol))
+
+	res := next(alice, "sys.presence")
+	require.Equal(t, "bob", res.Get("data.uid").String())
+	require.True(t, res.Get("data.online").Bool())
+
+	Dispatcher(alice, `{"id":"1","action":"sys.setStatus","params":"{\"status\":1}"}`)
+	res = next(alice, "sys.setStatus")
+	require.Equal(t, int64(0), res.Get("code").Int())
+	require.Equal(t, "1", res.Get("id").String())
+	require.Equal(t, int64(UserStatusBusy), next(bob, "sys.presence").Get("data.status").Int())
+
+	Dispatcher(alice, `{"action":"sys.setStatus","params":"{\"status\":9}"}`)
+	require.Equal(t, int64(ErrParamsInvalid.Code), next(alice, "sys.setStatus").Get("code").Int())
+
+	guest := &Client{Hub: s.Hub(), Send: make(chan []byte, 16)}
+	Dispatcher(guest, `{"action":"sys.setStatus","params":"{\"status\":1}"}`)
+	require.Equal(t, int64(ErrUncertified.Code), next(guest, "sys.setStatus").Get("code").Int())
+
+	//长时间没有请求自动离开，收到请求后恢复
+	require.True(t, s.SetStatus(alice.User, UserStatusOnline))
+	next(bob, "sys.presence")
+
+	clock.now.Add(int64(6 * time.Minute))
+	s.Hub().Sweep()
+	require.Equal(t, UserStatusLeaving, alice.User.OnlineStatus)
+	require.Equal(t, int64(UserStatusLeaving), next(bob, "sys.presence").Get("data.status").Int())
+
+	Dispatcher(alice, `{"action":"noop"}`)
+	require.Equal(t, UserStatusOnline, alice.User.OnlineStatus)
+	require.Equal(t, int64(UserStatusOnline), next(bob, "sys.presence").Get("data.status").Int())
+
+	//下线通知，其它分组的用户收不到
+	s.Hub().Disconnect <- alice
+	require.False(t, next(bob, "sys.presence").Get("data.online").Bool())
+
+	select {
+	case msg := <-carol.Send:
+		t.Fatalf("unexpected message %s", msg)
+	default:
+	}
+}
+
+func TestStatusHandlerMiddleware(t *testing.T) {
+	s := NewInstance(nil)
+	defer s.Close()
+
+	s.Manager().Add("sys.setStatus", HandlersChain{func(a *Context) {
+		a.SendCode(403, "denied")
+		a.Abort()
+	}, StatusHandler})
+
+	c := &Client{Hub: s.Hub(), Send: make(chan []byte, 16)}
+	require.NoError(t, s.Hub().UserLogin("alice", "web", c))
+
+	Dispatcher(c, `{"action":"sys.setStatus","params":"{\"status\":1}"}`)
+	for {
+		res := gjson.ParseBytes(<-c.Send)
+		if res.Get("action").String() == "sys.setStatus" {
+			require.Equal(t, int64(403), res.Get("code").Int())
+			break
+		}
+	}
+
+	require.Equal(t, UserStatusOnline, c.User.OnlineStatus)
+}
@@ -0,0 +1,103 @@
+package ws
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// ErrSessionExists SessionPolicy.RejectNew 时已在其它设备登录
+var ErrSessionExists = errors.New("already logged in on another device")
+
+// SessionPolicy 多端登录策略，按 appId 配置
+//
+//	SessionPolicy{}                                 同一app只保留最新登录的连接（默认）
+//	SessionPolicy{RejectNew: true}                  已登录时拒绝新的登录
+//	SessionPolicy{Max: 3}                           同一app最多3个连接同时在线
+//	SessionPolicy{Platforms: []string{"ios", "android"}} ios和android只能有一个在线
+type SessionPolicy struct {
+	Max       int      //同一app同时在线的连接数，0时为1，小于0不限制
+	RejectNew bool     //达到上限时拒绝新的登录，默认踢掉最早登录的连接
+	Platforms []string //互斥平台，用户只能在其中一个平台在线
+}
+
+// SessionDevice 踢下线时推送给旧连接的新设备信息
+type SessionDevice struct {
+	AppId     string    `json:"appId"`
+	Platform  string    `json:"platform,omitempty"`
+	IpAddress string    `json:"ipAddress,omitempty"`
+	LoginTime time.Time `json:"loginTime"`
+}
+
+func (p SessionPolicy) max() int {
+	if p.Max == 0 {
+		return 1
+	}
+
+	return p.Max
+}
+
+// conflicts client 登录 appId 时需要下线的连接，按登录先后排序
+func (p SessionPolicy) conflicts(clients []*Client, appId string, client *Client) []*Client {
+	var same, others []*Client
+	for _, c := range clients {
+		if c == client {
+			continue
+		}
+
+		if c.AppId == appId {
+			same = append(same, c)
+		} else if c.Platform != client.Platform && slices.Contains(p.Platforms, c.Platform) && slices.Contains(p.Platforms, client.Platform) {
+			others = append(others, c)
+		}
+	}
+
+	if max := p.max(); max > 0 && len(same) >= max {
+		others = append(others, same[:len(same)-max+1]...)
+	}
+
+	return others
+}
+
+// SetSessionPolicy 设置 appId 的多端登录策略，appId 为空时设置默认策略
+func (s *Server) SetSessionPolicy(appId string, policy SessionPolicy) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	if s.sessionPolicies == nil {
+		s.sessionPolicies = map[string]SessionPolicy{}
+	}
+
+	s.sessionPolicies[appId] = policy
+}
+
+func (s *Server) sessionPolicy(appId string) SessionPolicy {
+	s.sessionMu.RLock()
+	defer s.sessionMu.RUnlock()
+
+	policy, ok := s.sessionPolicies[appId]
+	if !ok {
+		policy = s.sessionPolicies[""]
+	}
+
+	return policy
+}
+
+// kick 清除登录状态，推送 sys.kicked 后断开连接
+func (c *Client) kick(by *Client, at time.Time) {
+	c.Log("xx", "Kicked by new login")
+	c.IsLogin = false
+	c.User = nil
+	c.sendLast((&Action{
+		Action: "sys.kicked",
+		Code:   -1008,
+		Msg:    "logged in on another device",
+		Data: &SessionDevice{
+			AppId:     by.AppId,
+			Platform:  by.Platform,
+			IpAddress: by.IpAddress,
+			LoginTime: at,
+		},
+	}).Encode())
+}
@@ -0,0 +1,77 @@
+package ws
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestSessionPolicy(t *testing.T) {
+	s := NewInstance(http.NewServeMux())
+	defer s.Close()
+
+	s.SetSessionPolicy("pc", SessionPolicy{RejectNew: true})
+	s.SetSessionPolicy("pad", SessionPolicy{Max: 2})
+	s.SetSessionPolicy("ios", SessionPolicy{Platforms: []string{"ios", "android"}})
+	s.SetSessionPolicy("android", SessionPolicy{Platforms: []string{"ios", "android"}})
+
+	login := func(appId, platform string) (*Client, error) {
+		c := &Client{Hub: s.Hub(), Send: make(chan []byte, 8), Platform: platform, IpAddress: "10.0.0.1"}
+		return c, s.Hub().UserLogin("alice", appId, c)
+	}
+
+	kicked := func(c *Client) gjson.Result {
+		msg := <-c.Send
+		require.True(t, c.sent(msg))
+		require.False(t, c.IsLogin)
+		require.Nil(t, c.User)
+
+		res := gjson.ParseBytes(msg)
+		require.Equal(t, "sys.kicked", res.Get("action").String())
+		return res
+	}
+
+	user := func() *User {
+		return s.Hub().User("alice")
+	}
+
+	//默认只保留最新登录的连接
+	web1, err := login("web", "web")
+	require.NoError(t, err)
+	web1.SendMsg([]byte(`{"action":"chat.msg"}`))
+	web2, err := login("web", "web")
+	require.NoError(t, err)
+
+	//踢下线前已排队的消息照常发送
+	require.False(t, web1.sent(<-web1.Send))
+	res := kicked(web1)
+	require.Equal(t, "web", res.Get("data.appId").String())
+	require.Equal(t, "10.0.0.1", res.Get("data.ipAddress").String())
+	require.Equal(t, web2, user().AppClient("web"))
+
+	//拒绝新的登录
+	pc, err := login("pc", "windows")
+	require.NoError(t, err)
+	_, err = login("pc", "windows")
+	require.ErrorIs(t, err, ErrSessionExists)
+	require.False(t, pc.Disconnecting.Load())
+
+	//同时在线数
+	pad1, _ := login("pad", "ipad")
+	pad2, _ := login("pad", "ipad")
+	require.False(t, pad1.Disconnecting.Load())
+	_, err = login("pad", "ipad")
+	require.NoError(t, err)
+	kicked(pad1)
+	require.False(t, pad2.Disconnecting.Load())
+
+	//互斥平台
+	ios, _ := login("ios", "ios")
+	_, err = login("android", "android")
+	require.NoError(t, err)
+	require.Equal(t, "android", kicked(ios).Get("data.platform").String())
+	require.Nil(t, user().AppClient("ios"))
+	require.Len(t, user().Clients(), 5)
+}
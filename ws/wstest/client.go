@@ -0,0 +1,207 @@
+package wstest
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wonli/aqi/ws"
+)
+
+// Client 记录发送给客户端的全部消息
+type Client struct {
+	*ws.Client
+	Conn *Conn
+
+	h        *Harness
+	mu       sync.Mutex
+	received []*Message
+	seen     map[*Message]bool
+}
+
+// Dispatch 执行action并返回对应的响应
+//
+// params 为字符串时原样作为参数，其他类型编码为JSON，没有响应时返回 nil
+func (c *Client) Dispatch(action string, params any) *Message {
+	c.h.t.Helper()
+
+	id := strconv.FormatUint(c.h.seq.Add(1), 10)
+	req, err := json.Marshal(map[string]string{
+		"id":     id,
+		"action": action,
+		"params": encodeParams(c.h, params),
+	})
+	if err != nil {
+		c.h.t.Fatalf("wstest: encode request: %s", err)
+	}
+
+	start := len(c.Messages())
+	ws.Dispatcher(c.Client, string(req))
+
+	messages := c.Messages()[start:]
+	for _, msg := range messages {
+		if msg.Id == id {
+			return msg
+		}
+	}
+
+	//路由不存在或被禁言时响应不带id
+	for _, msg := range messages {
+		if msg.Id == "" && msg.Code != 0 {
+			return msg
+		}
+	}
+
+	return nil
+}
+
+// Login 以 uid 登录，同 Hubc.UserLogin
+func (c *Client) Login(uid, appId string) {
+	c.h.t.Helper()
+
+	err := c.h.Hub.UserLogin(uid, appId, c.Client)
+	if err != nil {
+		c.h.t.Fatalf("wstest: login %s: %s", uid, err)
+	}
+}
+
+// Disconnect 模拟连接断开并等待 Hubc 处理完成
+func (c *Client) Disconnect() {
+	c.h.t.Helper()
+
+	c.Hub.Disconnect <- c.Client
+	deadline := time.Now().Add(Timeout)
+	for !c.Conn.Closed() {
+		if time.Now().After(deadline) {
+			c.h.t.Fatalf("wstest: client %s not closed", c.ClientId)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Messages 已收到的全部消息
+func (c *Client) Messages() []*Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.collect()
+	return append([]*Message(nil), c.received...)
+}
+
+// Last 最后收到的消息
+func (c *Client) Last() *Message {
+	messages := c.Messages()
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return messages[len(messages)-1]
+}
+
+// Wait 等待下一条指定action的消息，每条消息只返回一次
+func (c *Client) Wait(action string) *Message {
+	c.h.t.Helper()
+
+	msg := c.wait(action, Timeout)
+	if msg == nil {
+		c.h.t.Fatalf("wstest: no message for %q within %s", action, Timeout)
+	}
+
+	return msg
+}
+
+// WaitTopic 等待主题消息
+func (c *Client) WaitTopic(topicId string) *TopicMessage {
+	c.h.t.Helper()
+
+	msg := c.Wait(topicId)
+	var tm TopicMessage
+	err := msg.Bind(&tm)
+	if err != nil {
+		c.h.t.Fatalf("wstest: decode topic message: %s", err)
+	}
+
+	return &tm
+}
+
+// NoMessage 在 d 时间内没有收到指定action的消息
+func (c *Client) NoMessage(action string, d time.Duration) {
+	c.h.t.Helper()
+
+	msg := c.wait(action, d)
+	if msg != nil {
+		c.h.t.Fatalf("wstest: unexpected message for %q: code=%d msg=%s data=%s", action, msg.Code, msg.Msg, msg.Data)
+	}
+}
+
+func (c *Client) wait(action string, timeout time.Duration) *Message {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		c.mu.Lock()
+		c.collect()
+		for _, msg := range c.received {
+			if msg.Action == action && !c.seen[msg] {
+				c.seen[msg] = true
+				c.mu.Unlock()
+				return msg
+			}
+		}
+		c.mu.Unlock()
+
+		select {
+		case data, ok := <-c.Send:
+			if !ok {
+				return nil
+			}
+
+			c.record(data)
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
+// collect 读取发送队列中的消息，需持有锁
+func (c *Client) collect() {
+	for {
+		select {
+		case data, ok := <-c.Send:
+			if !ok {
+				return
+			}
+
+			c.received = append(c.received, decodeMessage(data))
+		default:
+			return
+		}
+	}
+}
+
+func (c *Client) record(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.received = append(c.received, decodeMessage(data))
+}
+
+func encodeParams(h *Harness, params any) string {
+	switch p := params.(type) {
+	case nil:
+		return "{}"
+	case string:
+		return p
+	case []byte:
+		return string(p)
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		h.t.Fatalf("wstest: encode params: %s", err)
+	}
+
+	return string(data)
+}
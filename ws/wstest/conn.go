@@ -0,0 +1,83 @@
+package wstest
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Conn 内存中的 net.Conn，读取阻塞到关闭，写入的数据保存在缓冲区
+type Conn struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	closed bool
+	done   chan struct{}
+	local  net.Addr
+	remote net.Addr
+}
+
+// NewConn 创建内存连接，remoteAddr 格式为 ip:port
+func NewConn(remoteAddr string) *Conn {
+	remote, err := net.ResolveTCPAddr("tcp", remoteAddr)
+	if err != nil {
+		remote = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	}
+
+	return &Conn{
+		done:   make(chan struct{}),
+		local:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2015},
+		remote: remote,
+	}
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	<-c.done
+	return 0, io.EOF
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+
+	return c.buf.Write(p)
+}
+
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+
+	return nil
+}
+
+// Closed 连接是否已被关闭
+func (c *Conn) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closed
+}
+
+// Written 直接写入连接的原始数据
+func (c *Conn) Written() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return bytes.Clone(c.buf.Bytes())
+}
+
+func (c *Conn) LocalAddr() net.Addr                { return c.local }
+func (c *Conn) RemoteAddr() net.Addr               { return c.remote }
+func (c *Conn) SetDeadline(t time.Time) error      { return nil }
+func (c *Conn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *Conn) SetWriteDeadline(t time.Time) error { return nil }
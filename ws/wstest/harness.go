@@ -0,0 +1,93 @@
+// Package wstest 提供在内存中测试 ws action 和中间件的工具
+//
+// 请求通过真实的 ws.Dispatcher 执行，客户端发送的消息全部被记录，
+// 每个 Harness 使用独立的 Server 和可手动推进的时钟
+package wstest
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wonli/aqi/ws"
+)
+
+// Timeout 等待消息的默认超时时间
+var Timeout = 2 * time.Second
+
+type Harness struct {
+	Server *ws.Server
+	Hub    *ws.Hubc
+	Clock  *Clock
+
+	t   testing.TB
+	seq atomic.Uint64
+}
+
+// New 创建测试环境，测试结束时关闭 Server
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	clock := NewClock(time.Now())
+	server := ws.NewInstance(http.NewServeMux())
+	server.Hub().Clock = clock
+	t.Cleanup(server.Close)
+
+	return &Harness{
+		Server: server,
+		Hub:    server.Hub(),
+		Clock:  clock,
+		t:      t,
+	}
+}
+
+// Router 注册路由，只对当前测试环境有效
+func (h *Harness) Router() ws.Routers {
+	return h.Server.Router()
+}
+
+// NewClient 创建连接到当前 Hubc 的客户端
+//
+// 客户端不经过 Hubc.Connection，未登录时不在 Guests 中
+func (h *Harness) NewClient(options ...func(c *ws.Client)) *Client {
+	n := h.seq.Add(1)
+	conn := NewConn("127.0.0.1:" + strconv.FormatUint(10000+n, 10))
+	c := &ws.Client{
+		Hub:            h.Hub,
+		Conn:           conn,
+		Transport:      ws.TransportWebsocket,
+		Send:           make(chan []byte, 256),
+		ClientId:       "wstest-" + strconv.FormatUint(n, 10),
+		IpAddress:      "127.0.0.1",
+		IpAddressPort:  conn.RemoteAddr().String(),
+		ConnectionTime: h.Clock.Now(),
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return &Client{
+		Client: c,
+		Conn:   conn,
+		h:      h,
+		seen:   map[*Message]bool{},
+	}
+}
+
+// Pub 发布主题消息
+func (h *Harness) Pub(topicId string, data any) {
+	h.Hub.PubSub.Pub(topicId, data)
+}
+
+// Advance 推进时钟
+func (h *Harness) Advance(d time.Duration) time.Time {
+	return h.Clock.Advance(d)
+}
+
+// Sweep 立即执行一次 Hubc 守护检查
+func (h *Harness) Sweep() {
+	h.Hub.Sweep()
+}
@@ -0,0 +1,100 @@
+package wstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/wonli/aqi/ws"
+)
+
+func TestDispatch(t *testing.T) {
+	h := New(t)
+
+	var calls []string
+	h.Router().Use(func(a *ws.Context) {
+		calls = append(calls, "mw")
+		a.Next()
+	}).Add("wstest.echo", func(a *ws.Context) {
+		calls = append(calls, "handler")
+		a.SendActionData("wstest.notice", ws.H{"n": 1})
+		a.Send(ws.H{"name": a.Get("name")})
+	})
+
+	c := h.NewClient()
+	res := c.Dispatch("wstest.echo", ws.H{"name": "aqi"})
+	require.NotNil(t, res)
+	require.Equal(t, 0, res.Code)
+	require.JSONEq(t, `{"name":"aqi"}`, string(res.Data))
+	require.Equal(t, []string{"mw", "handler"}, calls)
+	require.Len(t, c.Messages(), 2)
+	require.Equal(t, "wstest.notice", c.Wait("wstest.notice").Action)
+	require.Equal(t, h.Clock.Now(), c.LastRequestTime)
+
+	res = c.Dispatch("wstest.missing", nil)
+	require.Equal(t, -1005, res.Code)
+}
+
+func TestLoginAndPubSub(t *testing.T) {
+	h := New(t)
+	h.Router().Add("wstest.join", func(a *ws.Context) {
+		a.Sub(a.Get("room"))
+		a.SendOk()
+	})
+
+	alice := h.NewClient()
+	alice.Login("alice", "app")
+	require.Equal(t, alice.Client, h.Hub.UserClient("alice", "app"))
+	require.Equal(t, 0, alice.Dispatch("wstest.join", ws.H{"room": "r1"}).Code)
+
+	bob := h.NewClient()
+	bob.Login("bob", "app")
+
+	h.Pub("r1", ws.H{"text": "hi"})
+	tm := alice.WaitTopic("r1")
+
+	var data struct {
+		Text string `json:"text"`
+	}
+	require.NoError(t, tm.Bind(&data))
+	require.Equal(t, "hi", data.Text)
+	bob.NoMessage("r1", 50*time.Millisecond)
+}
+
+func TestClockAndSweep(t *testing.T) {
+	h := New(t)
+	h.Router().Add("wstest.ping", func(a *ws.Context) {
+		a.SendOk()
+	})
+
+	c := h.NewClient()
+	c.Login("carol", "app")
+
+	user := h.Hub.User("carol")
+	user.Banned(time.Minute)
+	require.Equal(t, h.Clock.Now().Add(time.Minute), *user.Ban)
+	require.Equal(t, -1001, c.Dispatch("wstest.ping", nil).Code)
+	user.Unban()
+
+	user.LastHeartbeatTime = h.Clock.Now()
+	c.Disconnect()
+	require.True(t, c.IsClosed())
+
+	h.Sweep()
+	require.NotNil(t, h.Hub.User("carol"))
+
+	h.Advance(5 * time.Minute)
+	h.Sweep()
+	require.Nil(t, h.Hub.User("carol"))
+}
+
+func TestIsolatedHub(t *testing.T) {
+	h1 := New(t)
+	h2 := New(t)
+
+	h1.NewClient().Login("dave", "app")
+	require.NotNil(t, h1.Hub.User("dave"))
+	require.Nil(t, h2.Hub.User("dave"))
+	require.NotSame(t, ws.Hub, h1.Hub)
+}
@@ -0,0 +1,19 @@
+package wstest
+
+import (
+	"encoding/json"
+
+	"github.com/wonli/aqi/client"
+)
+
+// Message 客户端收到的消息，与 client.Message 相同
+type Message = client.Message
+
+// TopicMessage 主题消息，与 client.TopicMessage 相同
+type TopicMessage = client.TopicMessage
+
+func decodeMessage(data []byte) *Message {
+	msg := &Message{}
+	_ = json.Unmarshal(data, msg)
+	return msg
+}